@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/coveooss/gotemplate/v3/utils"
+	"github.com/coveooss/multilogger/errors"
+)
+
+// Remove the generated files registered in the manifest that are not produced anymore (the file is marked as stale or its
+// source has been deleted). If all is set, all generated files are removed. If restore is set, the .original files are
+// moved back in place. Files modified since their generation are only removed if force is set. An error is returned if
+// there is no manifest in the target folder.
+func cleanGeneratedFiles(manifest *template.Manifest, all, restore, force, dryRun bool) error {
+	exists := func(file string) bool {
+		_, err := os.Stat(file)
+		return err == nil
+	}
+	relative := func(file string) string { return utils.Relative(utils.Pwd(), file) }
+	action := func(format string, args ...interface{}) {
+		if dryRun {
+			format = "[dry-run] " + format
+		}
+		fmt.Printf(format+"\n", args...)
+	}
+	if !exists(manifest.Filename()) {
+		// Without manifest, we do not know which files have been generated
+		return fmt.Errorf("no generated file registered in %s, use run --manifest to register them", relative(manifest.Filename()))
+	}
+
+	var errs errors.Array
+	for _, entry := range manifest.Entries() {
+		if restore && entry.Original != "" && exists(entry.Original) {
+			action("Restoring %s => %s", relative(entry.Original), relative(entry.File))
+			if !dryRun {
+				if err := os.Rename(entry.Original, entry.File); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				manifest.Remove(entry.File)
+			}
+			continue
+		}
+
+		sourceDeleted := entry.Source != "" && !exists(entry.Source) && (entry.Original == "" || !exists(entry.Original))
+		if !(all || entry.Stale || sourceDeleted) {
+			continue
+		}
+		if !exists(entry.File) {
+			manifest.Remove(entry.File)
+			continue
+		}
+		if entry.File == entry.Source {
+			// The source has been replaced by its result, removing it would lose the content
+			errs = append(errs, fmt.Errorf("%s has been generated in place, use --restore to get back the original file", relative(entry.File)))
+			continue
+		}
+		if hash, err := template.HashFile(entry.File); err != nil {
+			errs = append(errs, err)
+			continue
+		} else if hash != entry.Hash && !force {
+			errs = append(errs, fmt.Errorf("%s has been modified since its generation, use --force to remove it anyway", relative(entry.File)))
+			continue
+		}
+
+		action("Removing %s", relative(entry.File))
+		if !dryRun {
+			if err := os.Remove(entry.File); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			manifest.Remove(entry.File)
+		}
+	}
+
+	if !dryRun {
+		if err := manifest.Save(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.AsError()
+}
+
+// Write the manifest of the generated files (if any) and returns false if the manifest cannot be written.
+func saveManifest(manifest *template.Manifest) bool {
+	if manifest == nil {
+		return true
+	}
+	if err := manifest.Save(); err != nil {
+		errors.Printf("Unable to write the manifest: %v", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanGeneratedFiles(t *testing.T) {
+	folder := t.TempDir()
+	t.Chdir(folder)
+	write := func(name, content string) string {
+		file := filepath.Join(folder, name)
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		return file
+	}
+	kept, removed, modified := write("kept.txt.gt", "@(1+1)"), write("removed.txt.gt", "@(2+2)"), write("modified.txt.gt", "@(3+3)")
+	inPlace := write("in_place.txt", "@(4+4)")
+
+	manifest, err := template.LoadManifest(folder)
+	assert.NoError(t, err)
+	tpl := template.MustNewTemplate(folder, nil, "", nil).RecordManifest(manifest)
+	_, err = tpl.ProcessTemplates("", "", kept, removed, modified, inPlace)
+	assert.NoError(t, err)
+	assert.NoError(t, manifest.Save())
+	assert.Len(t, manifest.Entries(), 4)
+
+	// The sources are deleted and one of the generated files is modified by the user
+	assert.NoError(t, os.Remove(removed))
+	assert.NoError(t, os.Remove(modified))
+	write("modified.generated.txt", "changed")
+
+	assert.Error(t, cleanGeneratedFiles(manifest, false, false, false, true))
+	assert.FileExists(t, filepath.Join(folder, "removed.generated.txt"), "Nothing is removed in dry run mode")
+
+	err = cleanGeneratedFiles(manifest, false, false, false, false)
+	assert.EqualError(t, err, "modified.generated.txt has been modified since its generation, use --force to remove it anyway")
+	assert.NoFileExists(t, filepath.Join(folder, "removed.generated.txt"))
+	assert.FileExists(t, filepath.Join(folder, "kept.generated.txt"))
+	assert.Len(t, manifest.Entries(), 3)
+
+	assert.NoError(t, cleanGeneratedFiles(manifest, false, false, true, false))
+	assert.NoFileExists(t, filepath.Join(folder, "modified.generated.txt"))
+
+	// The original file is restored and the remaining generated files are removed
+	assert.NoError(t, cleanGeneratedFiles(manifest, true, true, false, false))
+	content, _ := os.ReadFile(inPlace)
+	assert.Equal(t, "@(4+4)", string(content))
+	assert.NoFileExists(t, inPlace+".original")
+	assert.NoFileExists(t, filepath.Join(folder, "kept.generated.txt"))
+	assert.Empty(t, manifest.Entries())
+	assert.NoFileExists(t, manifest.Filename())
+
+	err = cleanGeneratedFiles(manifest, true, false, false, false)
+	assert.EqualError(t, err, "no generated file registered in .gotemplate-manifest.json, use run --manifest to register them")
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/coveooss/kingpin/v2"
+)
+
+// Supported shells for the completion command
+const (
+	completionBash = "bash"
+	completionZsh  = "zsh"
+	completionFish = "fish"
+)
+
+// The kind of value expected by a flag or an argument.
+type completionKind int
+
+const (
+	completionNone    completionKind = iota // Free text (or no value for boolean flags)
+	completionFiles                         // File names
+	completionFolders                       // Folder names
+	completionValues                        // One of the predefined values
+)
+
+type completionValue struct {
+	kind   completionKind
+	values []string
+}
+
+type completionFlag struct {
+	completionValue
+	names      []string // The long names (including aliases), without the leading --
+	short      rune
+	help       string
+	isBool     bool
+	cumulative bool
+}
+
+type completionCommand struct {
+	name, help string
+	flags      []completionFlag
+	args       *completionValue // Set if the command accepts arguments
+	isDefault  bool
+}
+
+// The placeholders used by the flags that expect a file or a folder.
+var completionPlaceHolders = map[string]completionKind{
+	"file":   completionFiles,
+	"path":   completionFiles,
+	"folder": completionFolders,
+}
+
+// The arguments that are completed with file names.
+var completionFileArgs = map[string]bool{
+	"templates": true,
+}
+
+// The values accepted by the enum flags and arguments, indexed by their value since kingpin does not expose them.
+type completionEnums map[kingpin.Value][]string
+
+// Implemented by the values of the flags that could be repeated.
+type cumulativeValue interface {
+	IsCumulative() bool
+}
+
+func newCompletionFlags(model *kingpin.FlagGroupModel, enums completionEnums) (result []completionFlag) {
+	for _, flag := range model.Flags {
+		if flag.Hidden {
+			continue
+		}
+		entry := completionFlag{
+			names:  append([]string{flag.Name}, flag.Aliases...),
+			short:  flag.Short,
+			help:   flag.Help,
+			isBool: flag.IsBoolFlag(),
+		}
+		if value, ok := flag.Value.(cumulativeValue); ok {
+			entry.cumulative = value.IsCumulative()
+		}
+		if entry.isBool {
+			if len(flag.Default) > 0 && flag.Default[0] == "true" {
+				// The flags that are on by default (i.e. the addons) are useful mostly to turn them off
+				entry.names = append(entry.names, "no-"+flag.Name)
+			}
+		} else if options := enums[flag.Value]; options != nil {
+			entry.completionValue = completionValue{completionValues, options}
+		} else {
+			entry.kind = completionPlaceHolders[flag.PlaceHolder]
+		}
+		result = append(result, entry)
+	}
+	return
+}
+
+func newCompletionCommands(model *kingpin.ApplicationModel, enums completionEnums) (result []completionCommand) {
+	for _, command := range model.Commands {
+		if command.Hidden {
+			continue
+		}
+		entry := completionCommand{
+			name:      command.Name,
+			help:      command.Help,
+			flags:     newCompletionFlags(command.FlagGroupModel, enums),
+			isDefault: command.Default,
+		}
+		for _, arg := range command.Args {
+			if arg.Hidden {
+				continue
+			}
+			entry.args = &completionValue{}
+			if options := enums[arg.Value]; options != nil {
+				*entry.args = completionValue{completionValues, options}
+			} else if completionFileArgs[arg.Name] {
+				entry.args.kind = completionFiles
+			}
+		}
+		result = append(result, entry)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return
+}
+
+// Generate the completion script for the shell from the application model. The model must be obtained after all
+// flags have been defined (including the addons toggles) but before the parsing (to exclude the automatic shortcuts).
+// The enums supply the values proposed for the enum flags and arguments.
+func generateCompletion(out io.Writer, model *kingpin.ApplicationModel, enums completionEnums, shell string) error {
+	global, commands := newCompletionFlags(model.FlagGroupModel, enums), newCompletionCommands(model, enums)
+	var script string
+	switch shell {
+	case completionBash:
+		script = bashCompletion(model.Name, global, commands)
+	case completionZsh:
+		script = zshCompletion(model.Name, global, commands)
+	case completionFish:
+		script = fishCompletion(model.Name, global, commands)
+	default:
+		return fmt.Errorf("unsupported shell %s", shell)
+	}
+	_, err := fmt.Fprint(out, script)
+	return err
+}
+
+func (f completionFlag) options() []string {
+	options := make([]string, 0, len(f.names)+1)
+	for _, name := range f.names {
+		options = append(options, "--"+name)
+	}
+	if f.short != 0 {
+		options = append(options, "-"+string(f.short))
+	}
+	return options
+}
+
+func commandNames(commands []completionCommand) []string {
+	names := make([]string, len(commands))
+	for i := range commands {
+		names[i] = commands[i].name
+	}
+	return names
+}
+
+func bashCompletion(app string, global []completionFlag, commands []completionCommand) string {
+	var script strings.Builder
+	// Print the case entries completing the value of the flags
+	flagValues := func(indent string, flags []completionFlag) {
+		for _, flag := range flags {
+			if flag.isBool {
+				continue
+			}
+			fmt.Fprintf(&script, "%s%s) ", indent, strings.Join(flag.options(), "|"))
+			switch flag.kind {
+			case completionFiles:
+				script.WriteString(`COMPREPLY=($(compgen -f -- "$cur")); `)
+			case completionFolders:
+				script.WriteString(`COMPREPLY=($(compgen -d -- "$cur")); `)
+			case completionValues:
+				fmt.Fprintf(&script, `COMPREPLY=($(compgen -W "%s" -- "$cur")); `, strings.Join(flag.values, " "))
+			}
+			script.WriteString("return ;;\n")
+		}
+	}
+	allOptions := func(flags []completionFlag) (result []string) {
+		for _, flag := range flags {
+			result = append(result, flag.options()...)
+		}
+		return
+	}
+	function := "_" + strings.Replace(app, "-", "_", -1)
+
+	fmt.Fprintf(&script, "# bash completion for %[1]s (generated by %[1]s completion bash)\n", app)
+	fmt.Fprintf(&script, "%s() {\n", function)
+	script.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	script.WriteString("    local command=run args=\"\" values=\"\" flags i\n")
+	script.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	script.WriteString("        case \"${COMP_WORDS[i]}\" in\n")
+	fmt.Fprintf(&script, "            %s) command=\"${COMP_WORDS[i]}\"; break ;;\n", strings.Join(commandNames(commands), "|"))
+	script.WriteString("        esac\n    done\n\n")
+	fmt.Fprintf(&script, "    flags=\"%s\"\n", strings.Join(allOptions(global), " "))
+	script.WriteString("    case \"$prev\" in\n")
+	flagValues("        ", global)
+	script.WriteString("    esac\n\n")
+	script.WriteString("    case \"$command\" in\n")
+	for _, command := range commands {
+		fmt.Fprintf(&script, "        %s)\n", command.name)
+		fmt.Fprintf(&script, "            flags+=\" %s\"\n", strings.Join(allOptions(command.flags), " "))
+		if command.args != nil {
+			switch command.args.kind {
+			case completionFiles:
+				script.WriteString("            args=files\n")
+			case completionValues:
+				fmt.Fprintf(&script, "            values=\"%s\"\n", strings.Join(command.args.values, " "))
+			}
+		}
+		if command.isDefault {
+			fmt.Fprintf(&script, "            values+=\" %s\"\n", strings.Join(commandNames(commands), " "))
+		}
+		script.WriteString("            case \"$prev\" in\n")
+		flagValues("                ", command.flags)
+		script.WriteString("            esac\n            ;;\n")
+	}
+	script.WriteString("    esac\n\n")
+	script.WriteString("    if [[ $cur == -* ]]; then\n")
+	script.WriteString("        COMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n")
+	script.WriteString("        return\n    fi\n")
+	script.WriteString("    COMPREPLY=($(compgen -W \"$values\" -- \"$cur\"))\n")
+	script.WriteString("    if [[ $args == files ]]; then\n")
+	script.WriteString("        COMPREPLY+=($(compgen -f -- \"$cur\"))\n")
+	script.WriteString("    fi\n}\n\n")
+	fmt.Fprintf(&script, "complete -o filenames -o bashdefault -F %s %s\n", function, app)
+	return script.String()
+}
+
+var zshEscape = strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`)
+
+func zshCompletion(app string, global []completionFlag, commands []completionCommand) string {
+	var script strings.Builder
+	action := func(value *completionValue) string {
+		if value == nil {
+			return " "
+		}
+		switch value.kind {
+		case completionFiles:
+			return "_files"
+		case completionFolders:
+			return "_files -/"
+		case completionValues:
+			return "(" + strings.Join(value.values, " ") + ")"
+		}
+		return " "
+	}
+	specs := func(indent string, flags []completionFlag) {
+		for _, flag := range flags {
+			help := zshEscape.Replace(flag.help)
+			repeat := ""
+			if flag.cumulative {
+				repeat = "*"
+			}
+			for _, option := range flag.options() {
+				switch {
+				case flag.isBool:
+					fmt.Fprintf(&script, "%s'%s[%s]'\n", indent, option, help)
+				case len(option) == 2:
+					fmt.Fprintf(&script, "%s'%s%s+[%s]:%s:%s'\n", indent, repeat, option, help, flag.names[0], action(&flag.completionValue))
+				default:
+					fmt.Fprintf(&script, "%s'%s%s=[%s]:%s:%s'\n", indent, repeat, option, help, flag.names[0], action(&flag.completionValue))
+				}
+			}
+		}
+	}
+	function := "_" + strings.Replace(app, "-", "_", -1)
+
+	fmt.Fprintf(&script, "#compdef %[1]s\n# zsh completion for %[1]s (generated by %[1]s completion zsh)\n\n", app)
+	fmt.Fprintf(&script, "%s() {\n", function)
+	script.WriteString("    local command=run word\n")
+	script.WriteString("    for word in ${words[2,CURRENT-1]}; do\n")
+	script.WriteString("        case $word in\n")
+	fmt.Fprintf(&script, "            %s) command=$word; break ;;\n", strings.Join(commandNames(commands), "|"))
+	script.WriteString("        esac\n    done\n\n")
+	script.WriteString("    local -a commands=(\n")
+	for _, command := range commands {
+		fmt.Fprintf(&script, "        '%s:%s'\n", command.name, zshEscape.Replace(command.help))
+	}
+	script.WriteString("    )\n")
+	script.WriteString("    local -a flags=(\n")
+	specs("        ", global)
+	script.WriteString("    )\n\n")
+	script.WriteString("    case $command in\n")
+	for _, command := range commands {
+		fmt.Fprintf(&script, "        %s)\n", command.name)
+		script.WriteString("            flags+=(\n")
+		specs("                ", command.flags)
+		switch {
+		case command.isDefault:
+			fmt.Fprintf(&script, "                '*:template or command:{_describe -t commands command commands; %s}'\n", action(command.args))
+		case command.args != nil:
+			fmt.Fprintf(&script, "                '1:command:(%s)'\n", command.name)
+			fmt.Fprintf(&script, "                '*:argument:%s'\n", action(command.args))
+		default:
+			fmt.Fprintf(&script, "                '1:command:(%s)'\n", command.name)
+		}
+		script.WriteString("            )\n            ;;\n")
+	}
+	script.WriteString("    esac\n")
+	script.WriteString("    _arguments -s $flags\n}\n\n")
+	fmt.Fprintf(&script, "compdef %s %s\n", function, app)
+	return script.String()
+}
+
+var fishEscape = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+func fishCompletion(app string, global []completionFlag, commands []completionCommand) string {
+	var script strings.Builder
+	function := "__" + strings.Replace(app, "-", "_", -1) + "_command"
+	complete := func(condition string, flag completionFlag) {
+		for i, name := range flag.names {
+			fmt.Fprintf(&script, "complete -c %s%s -l %s", app, condition, name)
+			if i == 0 && flag.short != 0 {
+				fmt.Fprintf(&script, " -s %c", flag.short)
+			}
+			switch flag.kind {
+			case completionFiles:
+				script.WriteString(" -r -F")
+			case completionFolders:
+				script.WriteString(" -x -a '(__fish_complete_directories)'")
+			case completionValues:
+				fmt.Fprintf(&script, " -x -a '%s'", fishEscape.Replace(strings.Join(flag.values, " ")))
+			default:
+				if !flag.isBool {
+					script.WriteString(" -x")
+				}
+			}
+			fmt.Fprintf(&script, " -d '%s'\n", fishEscape.Replace(flag.help))
+		}
+	}
+
+	fmt.Fprintf(&script, "# fish completion for %[1]s (generated by %[1]s completion fish)\n\n", app)
+	fmt.Fprintf(&script, "function %s\n", function)
+	script.WriteString("    for word in (commandline -opc)[2..-1]\n")
+	script.WriteString("        switch $word\n")
+	fmt.Fprintf(&script, "            case %s\n", strings.Join(commandNames(commands), " "))
+	script.WriteString("                echo $word\n                return\n")
+	script.WriteString("        end\n    end\n")
+	for _, command := range commands {
+		if command.isDefault {
+			fmt.Fprintf(&script, "    echo %s\n", command.name)
+		}
+	}
+	script.WriteString("end\n\n")
+	fmt.Fprintf(&script, "complete -c %s -f\n", app)
+	for _, flag := range global {
+		complete("", flag)
+	}
+	for _, command := range commands {
+		condition := fmt.Sprintf(" -n 'test (%s) = %s'", function, command.name)
+		script.WriteString("\n")
+		for _, other := range commands {
+			if command.isDefault {
+				fmt.Fprintf(&script, "complete -c %s%s -a %s -d '%s'\n", app, condition, other.name, fishEscape.Replace(other.help))
+			}
+		}
+		for _, flag := range command.flags {
+			complete(condition, flag)
+		}
+		if command.args != nil {
+			switch command.args.kind {
+			case completionFiles:
+				fmt.Fprintf(&script, "complete -c %s%s -F\n", app, condition)
+			case completionValues:
+				fmt.Fprintf(&script, "complete -c %s%s -a '%s'\n", app, condition, fishEscape.Replace(strings.Join(command.args.values, " ")))
+			}
+		}
+	}
+	return script.String()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/coveooss/kingpin/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateCompletion(t *testing.T) {
+	app := kingpin.New("gotemplate", "")
+	app.Flag("razor", "Razor Addon (ON by default)").Default(true).Bool()
+	run := app.Command("run", "").Default()
+	run.Flag("import", "Import variables files").PlaceHolder("file").Short('i').Strings()
+	typeFlag := run.Flag("type", "Force the type used for the main context").Short('t')
+	typeFlag.Enum("json", "yaml")
+	run.Arg("templates", "Template files or commands to process").Strings()
+	list := app.Command("list", "Get detailed help on gotemplate functions")
+	formatFlag := list.Flag("format", "Output format")
+	formatFlag.Enum("text", "json")
+	list.Arg("filters", "List only functions that contains one of the filter").Strings()
+	enums := completionEnums{
+		typeFlag.Model().Value:   {"json", "yaml"},
+		formatFlag.Model().Value: {"text", "json"},
+	}
+
+	generate := func(shell string) string {
+		var out bytes.Buffer
+		assert.NoError(t, generateCompletion(&out, app.Model(), enums, shell))
+		return out.String()
+	}
+
+	bash := generate(completionBash)
+	for _, expected := range []string{
+		`flags="--help --razor --no-razor"`,
+		`--import|-i) COMPREPLY=($(compgen -f -- "$cur")); return ;;`,
+		`--type|-t) COMPREPLY=($(compgen -W "json yaml" -- "$cur")); return ;;`,
+		`--format) COMPREPLY=($(compgen -W "text json" -- "$cur")); return ;;`,
+		`values+=" list run"`,
+		`complete -o filenames -o bashdefault -F _gotemplate gotemplate`,
+	} {
+		assert.Contains(t, bash, expected)
+	}
+	if _, err := exec.LookPath("bash"); err == nil {
+		command := exec.Command("bash", "-n")
+		command.Stdin = strings.NewReader(bash)
+		output, err := command.CombinedOutput()
+		assert.NoError(t, err, string(output))
+	}
+
+	zsh := generate(completionZsh)
+	for _, expected := range []string{
+		`'--no-razor[Razor Addon (ON by default)]'`,
+		`'*-i+[Import variables files]:import:_files'`,
+		`'--type=[Force the type used for the main context]:type:(json yaml)'`,
+		`'-t+[Force the type used for the main context]:type:(json yaml)'`,
+		`'list:Get detailed help on gotemplate functions'`,
+		`'1:command:(list)'`,
+		`compdef _gotemplate gotemplate`,
+	} {
+		assert.Contains(t, zsh, expected)
+	}
+
+	fish := generate(completionFish)
+	for _, expected := range []string{
+		`complete -c gotemplate -l no-razor -d 'Razor Addon (ON by default)'`,
+		`complete -c gotemplate -n 'test (__gotemplate_command) = run' -l import -s i -r -F -d 'Import variables files'`,
+		`complete -c gotemplate -n 'test (__gotemplate_command) = run' -l type -s t -x -a 'json yaml'`,
+		`complete -c gotemplate -n 'test (__gotemplate_command) = run' -a list -d 'Get detailed help on gotemplate functions'`,
+	} {
+		assert.Contains(t, fish, expected)
+	}
+
+	assert.Error(t, generateCompletion(&bytes.Buffer{}, app.Model(), enums, "powershell"))
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/coveooss/gotemplate/v3/yaml"
+	"github.com/coveooss/kingpin/v2"
+	"github.com/coveooss/multilogger/errors"
+)
+
+const configFileName = ".gotemplate"
+
+var configFileExtensions = []string{".yaml", ".yml", ".json", ".hcl"}
+
+// Flags containing paths that are relative to the configuration file folder when they are defined in the configuration file
+var configPathFlags = map[string]bool{
+	"import":                 true,
+	"import-if-exist":        true,
+	"source":                 true,
+	"target":                 true,
+	"internal-log-file-path": true,
+}
+
+// Flags that are not reported when printing the effective configuration
+var configIgnoredFlags = map[string]bool{
+	"help":         true,
+	"version":      true,
+	"config":       true,
+	"print-config": true,
+}
+
+// Search for a configuration file (.gotemplate.yaml, .gotemplate.json, etc.) in the supplied folder and its parents.
+func findConfigFile(folder string) string {
+	folder, _ = filepath.Abs(folder)
+	for {
+		for _, ext := range configFileExtensions {
+			file := filepath.Join(folder, configFileName+ext)
+			if stat, err := os.Stat(file); err == nil && !stat.IsDir() {
+				return file
+			}
+		}
+		parent := filepath.Dir(folder)
+		if parent == folder {
+			return ""
+		}
+		folder = parent
+	}
+}
+
+// Returns the values of the flags explicitly supplied on the command line (or through their environment variable)
+// without actually setting the flags values.
+func preParse(app *kingpin.Application, args []string, flags ...*kingpin.FlagClause) map[string]string {
+	context, err := app.ParseContext(args)
+	if err != nil {
+		// Like for the real parsing, we try again by injecting the default command
+		context, _ = app.ParseContext(append([]string{"run"}, args...))
+	}
+
+	// The environment variables are only assigned to the flags once the application is initialized by ParseContext
+	result := make(map[string]string)
+	for _, flag := range flags {
+		if value := flag.GetEnvarValue(); value != "" {
+			result[flag.Model().Name] = value
+		}
+	}
+	if context == nil {
+		return result
+	}
+	for _, element := range context.Elements {
+		if flag, isFlag := element.Clause.(*kingpin.FlagClause); isFlag && element.Value != nil {
+			result[flag.Model().Name] = *element.Value
+		}
+	}
+	return result
+}
+
+// Use the values defined in the configuration file as the default values of the corresponding flags.
+// Since they are only default values, the command line flags and the environment variables take precedence.
+func applyConfig(app *kingpin.Application, filename string) error {
+	var content interface{}
+	if err := collections.LoadData(filename, &content); err != nil {
+		return fmt.Errorf("error while loading configuration file %s: %w", filename, err)
+	}
+	if content == nil {
+		// The file is empty
+		return nil
+	}
+	config, err := collections.TryAsDictionary(content)
+	if err != nil {
+		return fmt.Errorf("configuration file %s must contain a dictionary", filename)
+	}
+
+	flags := make(map[string][]*kingpin.FlagClause)
+	register := func(group flag, models []*kingpin.FlagModel) {
+		for _, model := range models {
+			for _, name := range append([]string{model.Name}, model.Aliases...) {
+				flags[name] = append(flags[name], group.GetFlag(model.Name))
+			}
+		}
+	}
+	register(app, app.Model().Flags)
+	for _, cmd := range app.Model().Commands {
+		register(app.GetCommand(cmd.Name), cmd.Flags)
+	}
+
+	var errs errors.Array
+	for _, key := range config.KeysAsString() {
+		clauses := flags[strings.ReplaceAll(key.Str(), "_", "-")]
+		if len(clauses) == 0 || configIgnoredFlags[clauses[0].Model().Name] {
+			errs = append(errs, fmt.Errorf("unknown configuration %s in %s", key, filename))
+			continue
+		}
+
+		var values []interface{}
+		if list, err := collections.TryAsList(config.Get(key)); err == nil {
+			values = list.AsArray()
+		} else {
+			values = []interface{}{config.Get(key)}
+		}
+		if configPathFlags[clauses[0].Model().Name] {
+			for i := range values {
+				if path := fmt.Sprint(values[i]); !filepath.IsAbs(path) {
+					values[i] = filepath.Join(filepath.Dir(filename), path)
+				}
+			}
+		}
+		for _, clause := range clauses {
+			clause.Default(values...)
+		}
+	}
+	return errs.AsError()
+}
+
+// Print the effective configuration (i.e. the values of all flags that apply to the selected command) as YAML.
+func printConfig(app *kingpin.Application, command, filename string) error {
+	models := app.Model().Flags
+	if cmd := app.GetCommand(command); cmd != nil {
+		models = append(models, cmd.Model().Flags...)
+	}
+
+	config := make(map[string]interface{}, len(models))
+	for _, model := range models {
+		if configIgnoredFlags[model.Name] || model.Hidden {
+			continue
+		}
+		getter, isGetter := model.Value.(kingpin.Getter)
+		if !isGetter {
+			continue
+		}
+		switch value := getter.Get().(type) {
+		case *[]string:
+			config[model.Name] = append([]string{}, *value...)
+		case bool, int, string:
+			config[model.Name] = value
+		default:
+			config[model.Name] = fmt.Sprint(value)
+		}
+	}
+
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if filename != "" {
+		fmt.Printf("# Configuration file: %s\n", filename)
+	}
+	for _, name := range names {
+		content, err := yaml.Marshal(map[string]interface{}{name: config[name]})
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(content))
+	}
+	return nil
+}
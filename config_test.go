@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/kingpin/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindConfigFile(t *testing.T) {
+	folder := t.TempDir()
+	subFolder := filepath.Join(folder, "sub", "folder")
+	assert.NoError(t, os.MkdirAll(subFolder, 0755))
+	assert.Empty(t, findConfigFile(subFolder))
+
+	configFile := filepath.Join(folder, configFileName+".yml")
+	assert.NoError(t, os.WriteFile(configFile, []byte("recursive: true"), 0644))
+	assert.Equal(t, configFile, findConfigFile(subFolder))
+	assert.Equal(t, configFile, findConfigFile(folder))
+
+	// The nearest configuration file wins
+	nearestFile := filepath.Join(subFolder, configFileName+".json")
+	assert.NoError(t, os.WriteFile(nearestFile, []byte("{}"), 0644))
+	assert.Equal(t, nearestFile, findConfigFile(subFolder))
+}
+
+func TestApplyConfig(t *testing.T) {
+	folder := t.TempDir()
+	configFile := filepath.Join(folder, configFileName+".yaml")
+	assert.NoError(t, os.WriteFile(configFile, []byte("recursive: true\nimport: [vars.json, /abs/vars.yaml]\nstrict_error_validation: true\ndel: '[[,]],@'\nvar: [a=1, b=2]\n"), 0644))
+
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		recursive  bool
+		strict     bool
+		delimiters string
+		vars       []string
+	}{
+		{
+			name:       "Configuration only",
+			recursive:  true,
+			strict:     true,
+			delimiters: "[[,]],@",
+			vars:       []string{"a=1", "b=2"},
+		},
+		{
+			name:       "Environment variables override configuration",
+			env:        map[string]string{"TEST_DELIMITERS": "<<,>>,@", "TEST_STRICT_ERROR_VALIDATION": "false"},
+			recursive:  true,
+			delimiters: "<<,>>,@",
+			vars:       []string{"a=1", "b=2"},
+		},
+		{
+			name:       "Flags override environment variables and configuration",
+			args:       []string{"--delimiters", "{{,}},#", "--var", "c=3"},
+			env:        map[string]string{"TEST_DELIMITERS": "<<,>>,@"},
+			recursive:  true,
+			strict:     true,
+			delimiters: "{{,}},#",
+			vars:       []string{"c=3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			app := kingpin.New("test", "").DefaultEnvars()
+			run := app.Command("run", "").Default()
+			recursive := run.Flag("recursive", "").Bool()
+			imports := run.Flag("import", "").Strings()
+			strict := run.Flag("strict-error-validation", "").Bool()
+			delimiters := run.Flag("delimiters", "").Alias("del").String()
+			vars := run.Flag("var", "").Strings()
+
+			assert.NoError(t, applyConfig(app, configFile))
+			_, err := app.Parse(append([]string{"run"}, tt.args...))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.recursive, *recursive)
+			assert.Equal(t, tt.strict, *strict)
+			assert.Equal(t, tt.delimiters, *delimiters)
+			assert.Equal(t, tt.vars, *vars)
+			assert.Equal(t, []string{filepath.Join(folder, "vars.json"), "/abs/vars.yaml"}, *imports)
+		})
+	}
+}
+
+func TestApplyConfigUnknownKey(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), configFileName+".json")
+	assert.NoError(t, os.WriteFile(configFile, []byte(`{"recursive": true, "unknown": 1}`), 0644))
+
+	app := kingpin.New("test", "")
+	app.Command("run", "").Default().Flag("recursive", "").Bool()
+	assert.EqualError(t, applyConfig(app, configFile), "unknown configuration unknown in "+configFile)
+}
+
+func TestDiscoveredConfigDoesNotDisableServeSandbox(t *testing.T) {
+	folder := t.TempDir()
+	configFile := filepath.Join(folder, configFileName+".yaml")
+	assert.NoError(t, os.WriteFile(configFile, []byte("sandbox: false\n"), 0644))
+
+	tests := []struct {
+		name    string
+		args    []string
+		sandbox bool
+	}{
+		{"Discovered configuration", []string{"serve", "--source", folder}, true},
+		{"Explicit configuration", []string{"serve", "--source", folder, "--config", configFile}, false},
+		{"Command line", []string{"serve", "--source", folder, "--no-sandbox"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldArgs := os.Args
+			defer func() { os.Args = oldArgs }()
+			os.Args = append([]string{"gotemplate"}, tt.args...)
+
+			c := newCommandLine()
+			exitCode, done := c.parse()
+			assert.Equal(t, 0, exitCode)
+			assert.False(t, done)
+			assert.Equal(t, tt.sandbox, *c.sandbox)
+		})
+	}
+}
@@ -1,24 +1,47 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 
 	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/coveooss/gotemplate/v3/hcl"
 	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/coveooss/gotemplate/v3/yaml"
 )
 
+// The origin of a value set in the context.
+type contextSource struct {
+	Source string // The flag (and its argument) that set the value
+	Value  interface{}
+}
+
+// The list of values set for each top level key of the context (in the order of application, so the last one wins).
+type contextProvenance map[string][]contextSource
+
 func createContext(varsFiles, varsFilesIfExist, namedVars []string, mode string, ignoreMissingFiles bool) (collections.IDictionary, error) {
+	context, _, err := createContextWithProvenance(varsFiles, varsFilesIfExist, namedVars, mode, ignoreMissingFiles)
+	return context, err
+}
+
+// Create the context and keep track of the origin of each top level key.
+// The import-if-exist files are applied first, then the import files, then the named variables and finally, the
+// unnamed values are set in ARGS.
+func createContextWithProvenance(varsFiles, varsFilesIfExist, namedVars []string, mode string, ignoreMissingFiles bool) (collections.IDictionary, contextProvenance, error) {
 	var context collections.IDictionary
+	provenance := make(contextProvenance)
 
 	type fileDef struct {
 		name     string
 		value    interface{}
 		unnamed  bool
 		required bool
+		source   string
 	}
 
 	if mode != "" {
@@ -29,13 +52,14 @@ func createContext(varsFiles, varsFilesIfExist, namedVars []string, mode string,
 
 	nameValuePairs := make([]fileDef, 0, len(varsFiles)+len(namedVars))
 	for i := range varsFilesIfExist {
-		nameValuePairs = append(nameValuePairs, fileDef{value: varsFilesIfExist[i]})
+		nameValuePairs = append(nameValuePairs, fileDef{value: varsFilesIfExist[i], source: "--import-if-exist " + varsFilesIfExist[i]})
 	}
 	for i := range varsFiles {
-		nameValuePairs = append(nameValuePairs, fileDef{value: varsFiles[i], required: true})
+		nameValuePairs = append(nameValuePairs, fileDef{value: varsFiles[i], required: true, source: "--import " + varsFiles[i]})
 	}
 
 	for i := range namedVars {
+		source := "--var " + namedVars[i]
 		data := collections.CreateDictionary().AsMap()
 		if err := collections.ConvertData(namedVars[i], &data); err != nil {
 			var fd fileDef
@@ -43,6 +67,7 @@ func createContext(varsFiles, varsFilesIfExist, namedVars []string, mode string,
 			if fd.value == "" {
 				fd = fileDef{value: fd.name, unnamed: true}
 			}
+			fd.source = source
 			nameValuePairs = append(nameValuePairs, fd)
 			continue
 		}
@@ -53,11 +78,12 @@ func createContext(varsFiles, varsFilesIfExist, namedVars []string, mode string,
 			data[name] = value
 		}
 		for key, value := range data {
-			nameValuePairs = append(nameValuePairs, fileDef{name: key, value: value})
+			nameValuePairs = append(nameValuePairs, fileDef{name: key, value: value, source: source})
 		}
 	}
 
 	var unnamed []interface{}
+	var unnamedSources []string
 	for _, nv := range nameValuePairs {
 		var loader func(string) (collections.IDictionary, error)
 		filename, _ := reflect.ValueOf(nv.value).Interface().(string)
@@ -93,6 +119,7 @@ func createContext(varsFiles, varsFilesIfExist, namedVars []string, mode string,
 					return nil, loadErr
 				} else if nv.name == "" {
 					unnamed = append(unnamed, content)
+					unnamedSources = append(unnamedSources, nv.source)
 					return nil, nil
 				}
 
@@ -125,6 +152,7 @@ func createContext(varsFiles, varsFilesIfExist, namedVars []string, mode string,
 				context = collections.CreateDictionary()
 			}
 			context.Set(nv.name, nv.value)
+			provenance[nv.name] = append(provenance[nv.name], contextSource{nv.source, nv.value})
 			continue
 		}
 		content, err := loader(filename)
@@ -133,7 +161,7 @@ func createContext(varsFiles, varsFilesIfExist, namedVars []string, mode string,
 				template.InternalLog.Debugf("Import: %s not found. Skipping the import", filename)
 				continue
 			} else {
-				return nil, fmt.Errorf("error %w while loading variable file %v", err, nv.value)
+				return nil, nil, fmt.Errorf("error %w while loading variable file %v", err, nv.value)
 			}
 		}
 		if content != nil {
@@ -147,12 +175,63 @@ func createContext(varsFiles, varsFilesIfExist, namedVars []string, mode string,
 			}
 			for key, value := range content.AsMap() {
 				context.Set(key, value)
+				provenance[key] = append(provenance[key], contextSource{nv.source, value})
 			}
 		}
 	}
 
 	if len(unnamed) > 0 {
 		context.Set("ARGS", unnamed)
+		provenance["ARGS"] = append(provenance["ARGS"], contextSource{strings.Join(unnamedSources, ", "), unnamed})
+	}
+	return context, provenance, nil
+}
+
+// Supported formats for the context command
+const (
+	contextFormatJSON = "json"
+	contextFormatYAML = "yaml"
+	contextFormatHCL  = "hcl"
+)
+
+// Print the context in the requested format. If explain is set, each top level key is replaced by the value, the source
+// that set the value and the list of values (with their sources) that have been overridden.
+func printContext(out io.Writer, context collections.IDictionary, provenance contextProvenance, format string, explain bool) error {
+	result := collections.CreateDictionary()
+	if context != nil {
+		for key, value := range context.AsMap() {
+			if !explain {
+				result.Set(key, value)
+				continue
+			}
+			entry := collections.CreateDictionary().Set("value", value)
+			if sources := provenance[key]; len(sources) > 0 {
+				entry.Set("source", sources[len(sources)-1].Source)
+				overrides := collections.CreateList()
+				for _, source := range sources[:len(sources)-1] {
+					overrides = overrides.Append(collections.CreateDictionary().Set("source", source.Source).Set("value", source.Value))
+				}
+				if overrides.Len() > 0 {
+					entry.Set("overrides", overrides)
+				}
+			}
+			result.Set(key, entry)
+		}
+	}
+
+	var content []byte
+	var err error
+	switch format {
+	case contextFormatJSON:
+		content, err = json.MarshalIndent(result.Native(), "", "  ")
+	case contextFormatHCL:
+		content, err = hcl.MarshalIndent(result.Native(), "", "  ")
+	default:
+		content, err = yaml.Marshal(result.Native())
+	}
+	if err != nil {
+		return err
 	}
-	return context, nil
+	_, err = fmt.Fprintln(out, strings.TrimSuffix(string(content), "\n"))
+	return err
 }
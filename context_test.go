@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextProvenance(t *testing.T) {
+	folder := t.TempDir()
+	t.Chdir(folder)
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "base.yaml"), []byte("a: 1\nb: base\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "override.json"), []byte(`{"a": 2, "c": [1, 2]}`), 0644))
+
+	context, provenance, err := createContextWithProvenance([]string{"override.json"}, []string{"base.yaml", "missing.yaml"}, []string{"a=3"}, "", false)
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	assert.NoError(t, printContext(&out, context, provenance, contextFormatJSON, false))
+	assert.JSONEq(t, `{"a": 3, "b": "base", "c": [1, 2]}`, out.String())
+
+	out.Reset()
+	assert.NoError(t, printContext(&out, context, provenance, contextFormatJSON, true))
+	assert.JSONEq(t, `{
+		"a": {
+			"value": 3,
+			"source": "--var a=3",
+			"overrides": [
+				{"source": "--import-if-exist base.yaml", "value": 1},
+				{"source": "--import override.json", "value": 2}
+			]
+		},
+		"b": {"value": "base", "source": "--import-if-exist base.yaml"},
+		"c": {"value": [1, 2], "source": "--import override.json"}
+	}`, out.String())
+
+	out.Reset()
+	assert.NoError(t, printContext(&out, context, provenance, contextFormatYAML, false))
+	assert.Equal(t, "a: 3\nb: base\nc:\n    - 1\n    - 2\n", out.String())
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/coveooss/multilogger/errors"
+)
+
+// Supported formats for the errors reporting
+const (
+	errorFormatText  = "text"
+	errorFormatJSON  = "json"
+	errorFormatSARIF = "sarif"
+)
+
+// Print the errors and the warnings in the requested format. In text mode, only the errors are printed (on stderr) since
+// the warnings have already been logged. Otherwise, a report containing all diagnostics is written in the output file or
+// printed on stderr if there is no output file (even if empty) to avoid mixing it with the rendered templates.
+func printDiagnostics(format, output, workingFolder string, err error, warnings []*template.TemplateError) {
+	if format == errorFormatText || format == "" {
+		if err != nil {
+			errors.Print(err)
+		}
+		return
+	}
+
+	diagnostics := make([]template.TemplateError, 0)
+	for _, diagnostic := range append(template.Diagnostics(err), warnings...) {
+		diagnostic := *diagnostic
+		if diagnostic.File != "" && diagnostic.File != "." {
+			if file, err := filepath.Abs(diagnostic.File); err == nil {
+				if file, err = filepath.Rel(workingFolder, file); err == nil {
+					diagnostic.File = filepath.ToSlash(file)
+				}
+			}
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+
+	var report interface{} = diagnostics
+	if format == errorFormatSARIF {
+		report = sarifReport(diagnostics)
+	}
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		errors.Print(err)
+		return
+	}
+	if output == "" {
+		fmt.Fprintln(os.Stderr, string(content))
+	} else if err = os.WriteFile(output, append(content, '\n'), 0644); err != nil {
+		errors.Print(err)
+	}
+}
+
+// Convert the diagnostics into a SARIF 2.1.0 log (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html).
+func sarifReport(diagnostics []template.TemplateError) map[string]interface{} {
+	results := make([]map[string]interface{}, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		result := map[string]interface{}{
+			"ruleId":  "gotemplate",
+			"level":   string(diagnostic.Severity),
+			"message": map[string]interface{}{"text": diagnostic.Message},
+		}
+		if diagnostic.File != "" && diagnostic.File != "." {
+			location := map[string]interface{}{
+				"artifactLocation": map[string]interface{}{"uri": diagnostic.File},
+			}
+			if diagnostic.Line > 0 {
+				region := map[string]interface{}{"startLine": diagnostic.Line}
+				if diagnostic.Column > 0 {
+					region["startColumn"] = diagnostic.Column
+				}
+				if diagnostic.Excerpt != "" {
+					region["snippet"] = map[string]interface{}{"text": diagnostic.Excerpt}
+				}
+				location["region"] = region
+			}
+			result["locations"] = []interface{}{map[string]interface{}{"physicalLocation": location}}
+		}
+		results = append(results, result)
+	}
+
+	return map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []interface{}{map[string]interface{}{
+			"tool": map[string]interface{}{
+				"driver": map[string]interface{}{
+					"name":           "gotemplate",
+					"informationUri": "https://github.com/coveooss/gotemplate",
+					"version":        version,
+				},
+			},
+			"results": results,
+		}},
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSarifReport(t *testing.T) {
+	report := sarifReport([]template.TemplateError{
+		{File: "folder/file.gt", Line: 3, Column: 7, Excerpt: "@foo()", Message: `function "foo" not defined`, Severity: template.SeverityError},
+		{File: ".", Message: "ignored", Severity: template.SeverityWarning},
+	})
+	content, err := json.Marshal(report["runs"].([]interface{})[0].(map[string]interface{})["results"])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{
+			"ruleId": "gotemplate",
+			"level": "error",
+			"message": {"text": "function \"foo\" not defined"},
+			"locations": [{"physicalLocation": {
+				"artifactLocation": {"uri": "folder/file.gt"},
+				"region": {"startLine": 3, "startColumn": 7, "snippet": {"text": "@foo()"}}
+			}}]
+		},
+		{"ruleId": "gotemplate", "level": "warning", "message": {"text": "ignored"}}
+	]`, string(content))
+}
+
+func TestPrintDiagnosticsToFile(t *testing.T) {
+	folder := t.TempDir()
+	output := filepath.Join(folder, "report.json")
+	err := fmt.Errorf("template: %s:2:3: unexpected error", filepath.Join(folder, "file.gt"))
+	printDiagnostics(errorFormatJSON, output, folder, err, nil)
+
+	content, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"file": "file.gt", "line": 2, "column": 3, "message": "unexpected error", "severity": "error"}]`, string(content))
+}
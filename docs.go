@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	html "html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/coveooss/gotemplate/v3/template"
+)
+
+// Supported formats for the docs command
+const (
+	docsFormatMarkdown = "markdown"
+	docsFormatJSON     = "json"
+	docsFormatHTML     = "html"
+)
+
+// Folders and files generated by the docs command
+const (
+	docsFunctionsFolder = "functions_reference"
+	docsObjectsFolder   = "objects"
+	docsAllFunctions    = "all_functions"
+	docsWrapWidth       = 69
+)
+
+var reDocsSlug = regexp.MustCompile(`[^\w]+`)
+
+// Name of the file documenting an object (without extension).
+func docsSlug(name string) string {
+	return strings.Trim(reDocsSlug.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// Name of the file documenting a category (without extension), the name is kept as is to preserve the published URLs.
+func docsCategoryFile(name string) string { return strings.ToLower(name) }
+
+// The absolute URL of the category on the documentation site. The links of the index are in raw HTML, so they are
+// not rewritten by Hugo and must refer to the published page (where the non word characters are replaced by dashes).
+func docsCategoryURL(name string) string {
+	return "/gotemplate/docs/" + docsFunctionsFolder + "/" + reDocsSlug.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// The objects files keep the names used by the published documentation.
+var docsObjectFiles = map[string]string{
+	"String":      "string_methods",
+	"StringArray": "string_array_methods",
+	"List":        "list_methods",
+	"Dictionary":  "dict_methods",
+}
+
+func docsObjectFile(name string) string {
+	if file, found := docsObjectFiles[name]; found {
+		return file
+	}
+	return strings.Replace(docsSlug(name), "-", "_", -1) + "_methods"
+}
+
+// An entry of the all functions index, aliases refer to the function they are associated with.
+type docsIndexEntry struct {
+	Name, Function, File string
+}
+
+// Return the index of all functions (including aliases) for the category.
+func docsIndex(category template.CategoryDoc) (result []docsIndexEntry) {
+	file := docsCategoryFile(category.Name)
+	for _, function := range category.Functions {
+		result = append(result, docsIndexEntry{function.Name, function.Name, file})
+		for _, alias := range function.Aliases {
+			result = append(result, docsIndexEntry{alias, function.Name, file})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return
+}
+
+// Generate the functions reference (one file per category plus an index of all functions) and the object
+// methods reference in the output folder. The json format produces a single file containing everything.
+func generateDocs(doc template.Documentation, format, output string) error {
+	if format == docsFormatJSON {
+		content, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeDocsFile(filepath.Join(output, docsFunctionsFolder+".json"), string(content)+"\n")
+	}
+
+	ext, render := ".md", renderMarkdownDocs
+	if format == docsFormatHTML {
+		ext, render = ".html", renderHTMLDocs
+	}
+	files := make(map[string]string)
+	if err := render(doc, ext, files); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeDocsFile(filepath.Join(output, name), files[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeDocsFile(file, content string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	template.InternalLog.Infof("Generating %s", file)
+	return os.WriteFile(file, []byte(content), 0644)
+}
+
+func docsWrap(text string, indent int) string {
+	wrapped := collections.WrapString(text, docsWrapWidth)
+	if indent > 0 {
+		wrapped = strings.TrimSpace(collections.Indent(wrapped, strings.Repeat(" ", indent)))
+	}
+	return wrapped
+}
+
+// Produce the markdown files (with the front matter expected by the documentation site).
+func renderMarkdownDocs(doc template.Documentation, ext string, files map[string]string) error {
+	var index strings.Builder
+	index.WriteString("---\nweight: 1\n---\n<!-- markdownlint-disable MD033 --->\n\n# All functions\n")
+
+	for _, category := range doc.Categories {
+		var content strings.Builder
+		content.WriteString("---\nbookToC: 2\nweight: 2\n---\n")
+		if category.URL != "" {
+			fmt.Fprintf(&content, "# [%s](%s)\n", category.Name, category.URL)
+		} else {
+			fmt.Fprintf(&content, "# %s\n", category.Name)
+		}
+		content.WriteString("<!-- markdownlint-disable MD033 MD024 --->\n")
+
+		for _, function := range category.Functions {
+			fmt.Fprintf(&content, "\n## __%s__\n\n```go\nfunc %s\n```\n", function.Name, docsWrap(function.Signature, 0))
+			if function.Description != "" {
+				fmt.Fprintf(&content, "\n```\n%s\n```\n", docsWrap(strings.Replace(function.Description, "<", "&lt;", -1), 0))
+			}
+			if len(function.Aliases) > 0 {
+				fmt.Fprintf(&content, "\n### Aliases\n\n- _%s_\n", strings.Join(function.Aliases, "_\n- _"))
+			}
+			if len(function.Examples) > 0 {
+				content.WriteString("\n### Examples\n")
+				for _, example := range function.Examples {
+					content.WriteString("\n```go\n")
+					for _, line := range []struct{ title, value string }{
+						{"Razor:", example.Razor},
+						{"Template:", example.Template},
+						{"Result:", example.Result},
+					} {
+						if line.value != "" {
+							fmt.Fprintf(&content, "%-10s%s\n", line.title, docsWrap(line.value, 10))
+						}
+					}
+					content.WriteString("```\n")
+				}
+			}
+		}
+		files[filepath.Join(docsFunctionsFolder, docsCategoryFile(category.Name)+ext)] = content.String()
+
+		fmt.Fprintf(&index, "\n## %s\n\n", category.Name)
+		for _, entry := range docsIndex(category) {
+			fmt.Fprintf(&index, "<span class=\"flink\"><a href=\"%s#%s\">%s</a></span>\n", docsCategoryURL(category.Name), strings.ToLower(entry.Function), entry.Name)
+		}
+	}
+	files[filepath.Join(docsFunctionsFolder, docsAllFunctions+ext)] = index.String()
+
+	for _, object := range doc.Objects {
+		files[filepath.Join(docsObjectsFolder, docsObjectFile(object.Name)+ext)] = fmt.Sprintf("# %s object\n\n```go\n%s\n```\n", object.Name, strings.Join(object.Methods, "\n"))
+	}
+	return nil
+}
+
+var docsHTMLTemplates = html.Must(html.New("page").Funcs(html.FuncMap{"lower": strings.ToLower}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+</head>
+<body>
+{{ template "body" . }}
+</body>
+</html>
+`))
+
+var docsHTMLCategory = html.Must(html.Must(docsHTMLTemplates.Clone()).New("body").Parse(`
+{{- with .Category -}}
+<h1>{{ if .URL }}<a href="{{ .URL }}">{{ .Name }}</a>{{ else }}{{ .Name }}{{ end }}</h1>
+{{- range .Functions }}
+<h2 id="{{ .Name | lower }}">{{ .Name }}</h2>
+<pre><code>func {{ .Signature }}</code></pre>
+{{- with .Description }}
+<p>{{ . }}</p>
+{{- end }}
+{{- with .Aliases }}
+<h3>Aliases</h3>
+<ul>
+{{- range . }}
+<li><em>{{ . }}</em></li>
+{{- end }}
+</ul>
+{{- end }}
+{{- with .Examples }}
+<h3>Examples</h3>
+{{- range . }}
+<pre><code>
+{{- with .Razor }}Razor:    {{ . }}{{ "\n" }}{{ end }}
+{{- with .Template }}Template: {{ . }}{{ "\n" }}{{ end }}
+{{- with .Result }}Result:   {{ . }}{{ "\n" }}{{ end -}}
+</code></pre>
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end }}`))
+
+var docsHTMLIndex = html.Must(html.Must(docsHTMLTemplates.Clone()).New("body").Parse(`<h1>All functions</h1>
+{{- range .Categories }}
+<h2>{{ .Name }}</h2>
+<p>
+{{- range .Entries }}
+<a href="{{ .File }}.html#{{ .Function | lower }}">{{ .Name }}</a>
+{{- end }}
+</p>
+{{- end }}`))
+
+var docsHTMLObject = html.Must(html.Must(docsHTMLTemplates.Clone()).New("body").Parse(`<h1>{{ .Object.Name }} object</h1>
+<pre><code>
+{{- range $i, $method := .Object.Methods }}{{ if $i }}{{ "\n" }}{{ end }}{{ $method }}{{ end -}}
+</code></pre>`))
+
+// Produce standalone HTML pages.
+func renderHTMLDocs(doc template.Documentation, ext string, files map[string]string) error {
+	execute := func(file string, t *html.Template, data map[string]interface{}) error {
+		var content strings.Builder
+		if err := t.ExecuteTemplate(&content, "page", data); err != nil {
+			return err
+		}
+		files[file] = content.String()
+		return nil
+	}
+
+	type indexCategory struct {
+		Name    string
+		Entries []docsIndexEntry
+	}
+	var index []indexCategory
+	for _, category := range doc.Categories {
+		file := filepath.Join(docsFunctionsFolder, docsCategoryFile(category.Name)+ext)
+		if err := execute(file, docsHTMLCategory, map[string]interface{}{"Title": category.Name, "Category": category}); err != nil {
+			return err
+		}
+		index = append(index, indexCategory{category.Name, docsIndex(category)})
+	}
+	file := filepath.Join(docsFunctionsFolder, docsAllFunctions+ext)
+	if err := execute(file, docsHTMLIndex, map[string]interface{}{"Title": "All functions", "Categories": index}); err != nil {
+		return err
+	}
+
+	for _, object := range doc.Objects {
+		file := filepath.Join(docsObjectsFolder, docsObjectFile(object.Name)+ext)
+		if err := execute(file, docsHTMLObject, map[string]interface{}{"Title": object.Name + " object", "Object": object}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	doc := template.Documentation{
+		Categories: []template.CategoryDoc{{
+			Name: "Sprig Date",
+			URL:  "http://masterminds.github.io/sprig/date.html",
+			Functions: []template.FuncDoc{{
+				Name:        "dateInZone",
+				Signature:   "dateInZone(fmt string, date interface{}, zone string) string",
+				Description: "Same as date, but with a timezone.",
+				Aliases:     []string{"date_in_zone"},
+				Examples:    []template.Example{{Razor: `@dateInZone("2006", 0, "UTC")`, Result: "1970"}},
+			}},
+		}},
+		Objects: []template.ObjectDoc{{Name: "StringArray", Methods: []string{"Len() int", "Str() []string"}}},
+	}
+	read := func(file string) string {
+		content, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		return string(content)
+	}
+
+	folder := t.TempDir()
+	assert.NoError(t, generateDocs(doc, docsFormatMarkdown, folder))
+	assert.Equal(t, "---\nbookToC: 2\nweight: 2\n---\n"+
+		"# [Sprig Date](http://masterminds.github.io/sprig/date.html)\n"+
+		"<!-- markdownlint-disable MD033 MD024 --->\n\n"+
+		"## __dateInZone__\n\n"+
+		"```go\nfunc dateInZone(fmt string, date interface{}, zone string) string\n```\n\n"+
+		"```\nSame as date, but with a timezone.\n```\n\n"+
+		"### Aliases\n\n- _date_in_zone_\n\n"+
+		"### Examples\n\n"+
+		"```go\nRazor:    @dateInZone(\"2006\", 0, \"UTC\")\nResult:   1970\n```\n",
+		read(filepath.Join(folder, "functions_reference", "sprig date.md")))
+	assert.Equal(t, "---\nweight: 1\n---\n<!-- markdownlint-disable MD033 --->\n\n# All functions\n\n"+
+		"## Sprig Date\n\n"+
+		"<span class=\"flink\"><a href=\"/gotemplate/docs/functions_reference/sprig-date#dateinzone\">dateInZone</a></span>\n"+
+		"<span class=\"flink\"><a href=\"/gotemplate/docs/functions_reference/sprig-date#dateinzone\">date_in_zone</a></span>\n",
+		read(filepath.Join(folder, "functions_reference", "all_functions.md")))
+	assert.Equal(t, "# StringArray object\n\n```go\nLen() int\nStr() []string\n```\n",
+		read(filepath.Join(folder, "objects", "string_array_methods.md")))
+
+	folder = t.TempDir()
+	assert.NoError(t, generateDocs(doc, docsFormatHTML, folder))
+	content := read(filepath.Join(folder, "functions_reference", "sprig date.html"))
+	assert.Contains(t, content, `<h2 id="dateinzone">dateInZone</h2>`)
+	assert.Contains(t, content, "<pre><code>Razor:    @dateInZone(&#34;2006&#34;, 0, &#34;UTC&#34;)\nResult:   1970\n</code></pre>")
+	assert.Contains(t, read(filepath.Join(folder, "functions_reference", "all_functions.html")), `<a href="sprig%20date.html#dateinzone">date_in_zone</a>`)
+	assert.Contains(t, read(filepath.Join(folder, "objects", "string_array_methods.html")), "<pre><code>Len() int\nStr() []string</code></pre>")
+
+	folder = t.TempDir()
+	assert.NoError(t, generateDocs(doc, docsFormatJSON, folder))
+	assert.Contains(t, read(filepath.Join(folder, "functions_reference.json")), `"aliases": [
+            "date_in_zone"
+          ]`)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/coveooss/gotemplate/v3/utils"
+	"github.com/fatih/color"
+)
+
+// Extensions of the golden files expected next to the tested sources
+const (
+	goldenRazorExt    = ".razor"    // Result of the razor conversion (as with --disable)
+	goldenRenderedExt = ".rendered" // Result of the rendering
+)
+
+// Render the sources and compare the results with the golden files (file.razor and file.rendered for file.gt).
+// The sources without golden file are skipped, unless update is set. In that case, the golden files are created
+// (the razor one only if the source contains razor code). It returns the number of failed tests.
+func runGoldenTests(t *template.Template, out io.Writer, update bool, sources ...string) (failed int) {
+	var passed, skipped, updated int
+	for _, source := range sources {
+		content, err := os.ReadFile(source)
+		if err != nil {
+			fmt.Fprintf(out, "%s %s: %v\n", color.RedString("FAIL"), source, err)
+			failed++
+			continue
+		}
+
+		base := strings.TrimSuffix(source, filepath.Ext(source))
+		goldenFiles := map[string]bool{}
+		for _, ext := range []string{goldenRazorExt, goldenRenderedExt} {
+			if _, err := os.Stat(base + ext); err == nil {
+				goldenFiles[ext] = true
+			}
+		}
+		if len(goldenFiles) == 0 {
+			if !update {
+				template.InternalLog.Infof("No golden file for %s", source)
+				skipped++
+				continue
+			}
+			goldenFiles[goldenRenderedExt] = true
+			goldenFiles[goldenRazorExt] = t.IsRazor(string(content))
+		}
+
+		var failures []string
+		var modified bool
+		for _, ext := range []string{goldenRazorExt, goldenRenderedExt} {
+			if !goldenFiles[ext] {
+				continue
+			}
+			t.SetOption(template.RenderingDisabled, ext == goldenRazorExt)
+			result, err := t.ProcessContent(string(content), source)
+			if err != nil {
+				failures = append(failures, err.Error())
+				continue
+			}
+
+			golden := base + ext
+			expected, _ := os.ReadFile(golden)
+			if update {
+				if string(expected) != result {
+					if err = os.WriteFile(golden, []byte(result), 0644); err != nil {
+						failures = append(failures, err.Error())
+					}
+					modified = true
+				}
+				continue
+			}
+			diff, err := utils.UnifiedDiff(filepath.ToSlash(golden), "result", string(expected), result)
+			if err != nil {
+				failures = append(failures, err.Error())
+			} else if diff != "" {
+				failures = append(failures, strings.TrimSuffix(diff, "\n"))
+			}
+		}
+		t.SetOption(template.RenderingDisabled, false)
+
+		switch {
+		case len(failures) > 0:
+			fmt.Fprintf(out, "%s %s\n%s\n", color.RedString("FAIL"), source, strings.Join(failures, "\n"))
+			failed++
+		case modified:
+			fmt.Fprintf(out, "%s %s\n", color.YellowString("UPDATED"), source)
+			updated++
+		default:
+			fmt.Fprintf(out, "%s %s\n", color.GreenString("PASS"), source)
+			passed++
+		}
+	}
+
+	summary := fmt.Sprintf("%d passed, %d failed, %d skipped", passed, failed, skipped)
+	if update {
+		summary += fmt.Sprintf(", %d updated", updated)
+	}
+	fmt.Fprintln(out, summary)
+	return
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunGoldenTests(t *testing.T) {
+	folder := t.TempDir()
+	t.Chdir(folder)
+	write := func(name, content string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(folder, name), []byte(content), 0644))
+	}
+	read := func(name string) string {
+		content, _ := os.ReadFile(filepath.Join(folder, name))
+		return string(content)
+	}
+	write("pass.gt", "@(1+2)")
+	write("pass.razor", "{{ add 1 2 }}")
+	write("pass.rendered", "3")
+	write("fail.gt", "Hello @name")
+	write("fail.rendered", "Hello you\n")
+	write("new.gt", "@(2*3)")
+
+	tpl := template.MustNewTemplate(folder, map[string]interface{}{"name": "world"}, "", nil)
+	var out bytes.Buffer
+	assert.Equal(t, 1, runGoldenTests(tpl, &out, false, "pass.gt", "fail.gt", "new.gt"))
+	assert.Equal(t, `PASS pass.gt
+FAIL fail.gt
+--- fail.rendered
++++ result
+@@ -1 +1 @@
+-Hello you
++Hello world
+\ No newline at end of file
+1 passed, 1 failed, 1 skipped
+`, out.String())
+
+	out.Reset()
+	assert.Equal(t, 0, runGoldenTests(tpl, &out, true, "pass.gt", "fail.gt", "new.gt"))
+	assert.Equal(t, "PASS pass.gt\nUPDATED fail.gt\nUPDATED new.gt\n1 passed, 0 failed, 0 skipped, 2 updated\n", out.String())
+	assert.Equal(t, "Hello world", read("fail.rendered"))
+	assert.Equal(t, "{{ mul 2 3 }}", read("new.razor"))
+	assert.Equal(t, "6", read("new.rendered"))
+}
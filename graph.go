@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/coveooss/gotemplate/v3/template"
+)
+
+// Supported formats for the graph command
+const (
+	graphFormatDOT  = "dot"
+	graphFormatJSON = "json"
+)
+
+// Print the dependencies as a JSON list or as a Graphviz digraph (https://graphviz.org/doc/info/lang.html).
+func printGraph(out io.Writer, dependencies []template.Dependency, format string) error {
+	if format == graphFormatJSON {
+		if dependencies == nil {
+			dependencies = []template.Dependency{}
+		}
+		content, err := json.MarshalIndent(dependencies, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(content))
+		return err
+	}
+
+	// The shape of the nodes depends on their nature (file, sub-template or command)
+	shapes := make(map[string]string)
+	var nodes []string
+	setShape := func(node, shape string) {
+		if _, found := shapes[node]; !found {
+			nodes = append(nodes, node)
+			shapes[node] = "box"
+		}
+		if shape != "" {
+			shapes[node] = shape
+		}
+	}
+	for _, dependency := range dependencies {
+		switch dependency.Kind {
+		case template.DependencyTemplate:
+			setShape(dependency.From, "")
+			setShape(dependency.To, "ellipse")
+		case template.DependencyDefinition:
+			setShape(dependency.From, "ellipse")
+			setShape(dependency.To, "")
+		case template.DependencyCommand:
+			setShape(dependency.From, "")
+			setShape(dependency.To, "diamond")
+		default:
+			setShape(dependency.From, "")
+			setShape(dependency.To, "")
+		}
+	}
+
+	fmt.Fprintln(out, "digraph gotemplate {")
+	fmt.Fprintln(out, "  rankdir=LR;")
+	for _, node := range nodes {
+		fmt.Fprintf(out, "  %s [shape=%s];\n", strconv.Quote(node), shapes[node])
+	}
+	for _, dependency := range dependencies {
+		style := ""
+		if dependency.Kind == template.DependencyDefinition {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(out, "  %s -> %s [label=%q%s];\n", strconv.Quote(dependency.From), strconv.Quote(dependency.To), dependency.Kind, style)
+	}
+	_, err := fmt.Fprintln(out, "}")
+	return err
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintGraph(t *testing.T) {
+	dependencies := []template.Dependency{
+		{From: "main.gt", To: "header", Kind: template.DependencyTemplate},
+		{From: "main.gt", To: "git status", Kind: template.DependencyCommand},
+		{From: "header", To: "ext.gte", Kind: template.DependencyDefinition},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, printGraph(&out, dependencies, graphFormatDOT))
+	assert.Equal(t, `digraph gotemplate {
+  rankdir=LR;
+  "main.gt" [shape=box];
+  "header" [shape=ellipse];
+  "git status" [shape=diamond];
+  "ext.gte" [shape=box];
+  "main.gt" -> "header" [label="template"];
+  "main.gt" -> "git status" [label="command"];
+  "header" -> "ext.gte" [label="definition", style=dashed];
+}
+`, out.String())
+
+	out.Reset()
+	assert.NoError(t, printGraph(&out, nil, graphFormatJSON))
+	assert.Equal(t, "[]\n", out.String())
+}
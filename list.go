@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/coveooss/gotemplate/v3/yaml"
+)
+
+// Supported formats for the list command
+const (
+	listFormatText = "text"
+	listFormatJSON = "json"
+	listFormatYAML = "yaml"
+)
+
+// The machine readable result of the list command, only the requested sections are included.
+type listResult struct {
+	Functions []template.FunctionDescription `json:"functions,omitempty" yaml:"functions,omitempty"`
+	Templates []template.TemplateDescription `json:"templates,omitempty" yaml:"templates,omitempty"`
+}
+
+// Print the functions and the templates as a JSON or YAML document (to be consumed by editors or scripts).
+func printList(out io.Writer, result listResult, format string) error {
+	var content []byte
+	var err error
+	if format == listFormatJSON {
+		content, err = json.MarshalIndent(result, "", "  ")
+		content = append(content, '\n')
+	} else {
+		content, err = yaml.Marshal(result)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(out, string(content))
+	return err
+}
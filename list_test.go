@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintList(t *testing.T) {
+	result := listResult{
+		Functions: []template.FunctionDescription{{Name: "trim", Group: "Sprig Strings", Signature: "trim(str string) string", Arguments: "str string", Result: "string"}},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, printList(&out, result, listFormatJSON))
+	assert.Equal(t, `{
+  "functions": [
+    {
+      "name": "trim",
+      "group": "Sprig Strings",
+      "signature": "trim(str string) string",
+      "arguments": "str string",
+      "result": "string",
+      "isAlias": false
+    }
+  ]
+}
+`, out.String())
+
+	out.Reset()
+	result.Functions = nil
+	result.Templates = []template.TemplateDescription{{Name: "header", File: "ext.gte"}}
+	assert.NoError(t, printList(&out, result, listFormatYAML))
+	assert.Equal(t, "templates:\n    - name: header\n      file: ext.gte\n", out.String())
+}
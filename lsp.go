@@ -0,0 +1,494 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/coveooss/gotemplate/v3/template"
+)
+
+// Error codes and constants defined by JSON-RPC and the language server protocol
+// (https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/).
+const (
+	lspParseError          = -32700
+	lspMethodNotFound      = -32601
+	lspInvalidParams       = -32602
+	lspInvalidRequest      = -32600
+	lspTextDocumentSync    = 1 // The whole document is sent on each change
+	lspSeverityError       = 1
+	lspCompletionFunction  = 3
+	lspInsertTextAsSnippet = 2
+)
+
+type lspRequest struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"` // Expressed in UTF-16 code units
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspDocumentPosition struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+type lspCompletionItem struct {
+	Label            string      `json:"label"`
+	Kind             int         `json:"kind"`
+	Detail           string      `json:"detail,omitempty"`
+	Documentation    interface{} `json:"documentation,omitempty"`
+	InsertText       string      `json:"insertText,omitempty"`
+	InsertTextFormat int         `json:"insertTextFormat,omitempty"`
+}
+
+// The state of the language server, the documents are indexed by their URI.
+type lspServer struct {
+	template  *template.Template
+	folder    string
+	functions []template.FunctionDescription
+	templates []template.TemplateDescription
+	documents map[string]string
+	out       io.Writer
+	shutdown  bool
+}
+
+// Serve the language server protocol requests read from the input until the exit notification (or the end of input)
+// is received. The functions and the templates defined by the extensions are the ones known by the supplied template
+// (loaded from folder), the other templates are only known while they are opened by the editor.
+func runLsp(t *template.Template, folder string, in io.Reader, out io.Writer) error {
+	context := t.GetNewContext("", false)
+	server := &lspServer{
+		template:  context,
+		folder:    folder,
+		functions: context.DescribeFunctions(true),
+		templates: context.DescribeTemplates(false),
+		documents: make(map[string]string),
+		out:       out,
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		content, err := readLspMessage(reader)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var request lspRequest
+		if err := json.Unmarshal(content, &request); err != nil {
+			server.reply(nil, nil, &lspError{lspParseError, err.Error()})
+			continue
+		}
+		if request.Method == "exit" {
+			if !server.shutdown {
+				return fmt.Errorf("exit requested before shutdown")
+			}
+			return nil
+		}
+		result, lspErr := server.handle(request)
+		if request.ID != nil {
+			server.reply(request.ID, result, lspErr)
+		}
+	}
+}
+
+// Read a message preceded by its headers (only Content-Length is considered).
+func readLspMessage(reader *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(line) != "" {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if length < 0 {
+				// Empty lines between messages are ignored
+				continue
+			}
+			break
+		}
+		if name, value := collections.Split2(line, ":"); strings.EqualFold(name, "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("invalid Content-Length header: %s", value)
+			}
+		}
+	}
+	content := make([]byte, length)
+	_, err := io.ReadFull(reader, content)
+	return content, err
+}
+
+func (s *lspServer) write(message interface{}) {
+	content, err := json.Marshal(message)
+	if err != nil {
+		template.InternalLog.Errorf("Unable to encode LSP message: %v", err)
+		return
+	}
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(content), content)
+}
+
+func (s *lspServer) reply(id *json.RawMessage, result interface{}, err *lspError) {
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+	if err != nil {
+		response["error"] = err
+	} else {
+		response["result"] = result
+	}
+	s.write(response)
+}
+
+func (s *lspServer) notify(method string, params interface{}) {
+	s.write(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (s *lspServer) handle(request lspRequest) (interface{}, *lspError) {
+	switch request.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   lspTextDocumentSync,
+				"completionProvider": map[string]interface{}{"triggerCharacters": []string{"@", "."}},
+				"hoverProvider":      true,
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]interface{}{"name": "gotemplate", "version": version},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen", "textDocument/didChange", "textDocument/didClose":
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, &lspError{lspInvalidParams, err.Error()}
+		}
+		uri := params.TextDocument.URI
+		switch request.Method {
+		case "textDocument/didOpen":
+			s.documents[uri] = params.TextDocument.Text
+		case "textDocument/didChange":
+			if changes := params.ContentChanges; len(changes) > 0 {
+				// The whole document is supplied since we only support full synchronization
+				s.documents[uri] = changes[len(changes)-1].Text
+			}
+		default:
+			delete(s.documents, uri)
+		}
+		s.publishDiagnostics(uri)
+		return nil, nil
+	case "textDocument/completion", "textDocument/hover", "textDocument/definition":
+		var params lspDocumentPosition
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, &lspError{lspInvalidParams, err.Error()}
+		}
+		content, open := s.documents[params.TextDocument.URI]
+		if !open {
+			return nil, &lspError{lspInvalidRequest, fmt.Sprintf("document %s is not opened", params.TextDocument.URI)}
+		}
+		line, offset := lineAt(content, params.Position)
+		switch request.Method {
+		case "textDocument/completion":
+			return s.completion(line, offset), nil
+		case "textDocument/hover":
+			return s.hover(line, offset), nil
+		default:
+			return s.definition(params.TextDocument.URI, line, offset), nil
+		}
+	}
+	if request.ID == nil || strings.HasPrefix(request.Method, "$/") {
+		// The unsupported notifications are ignored
+		return nil, nil
+	}
+	return nil, &lspError{lspMethodNotFound, fmt.Sprintf("method %s is not supported", request.Method)}
+}
+
+// Parse the document (after razor conversion) and report the errors to the editor (an empty list is sent when the
+// document is closed to clear the previous diagnostics).
+func (s *lspServer) publishDiagnostics(uri string) {
+	diagnostics := make([]lspDiagnostic, 0)
+	if content, open := s.documents[uri]; open {
+		lines := strings.Split(content, "\n")
+		for _, diagnostic := range template.Diagnostics(s.template.CheckContent(content, uriToPath(uri))) {
+			var position lspPosition
+			var end int
+			if diagnostic.Line > 0 && diagnostic.Line <= len(lines) {
+				line := lines[diagnostic.Line-1]
+				column := diagnostic.Column - 1
+				if column < 0 || column > len(line) {
+					column = 0
+				}
+				position = lspPosition{diagnostic.Line - 1, utf16Length(line[:column])}
+				end = utf16Length(line)
+			}
+			diagnostics = append(diagnostics, lspDiagnostic{
+				Range:    lspRange{position, lspPosition{position.Line, end}},
+				Severity: lspSeverityError,
+				Source:   "gotemplate",
+				Message:  diagnostic.Message,
+			})
+		}
+	}
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": uri, "diagnostics": diagnostics})
+}
+
+// Returns the functions starting with the word at the cursor. The arguments are inserted as snippet placeholders
+// using the razor (name(args)) or the go template (name args) syntax depending on the cursor location.
+func (s *lspServer) completion(line string, offset int) []lspCompletionItem {
+	start := offset
+	for start > 0 && isLspIdentifier(line[start-1]) {
+		start--
+	}
+	prefix := line[start:offset]
+	goTemplate := strings.LastIndex(line[:start], s.template.LeftDelim()) > strings.LastIndex(line[:start], s.template.RightDelim())
+
+	namespace, partial := "", prefix
+	if pos := strings.LastIndex(prefix, "."); pos >= 0 {
+		namespace, partial = prefix[:pos], prefix[pos+1:]
+	}
+	items := make([]lspCompletionItem, 0)
+	for _, function := range s.functions {
+		if namespace != "" && function.Namespace != namespace || !strings.HasPrefix(function.Name, partial) {
+			continue
+		}
+		items = append(items, lspCompletionItem{
+			Label:            function.Name,
+			Kind:             lspCompletionFunction,
+			Detail:           function.Signature,
+			Documentation:    function.Description,
+			InsertText:       lspSnippet(function, !goTemplate),
+			InsertTextFormat: lspInsertTextAsSnippet,
+		})
+	}
+	return items
+}
+
+func lspSnippet(function template.FunctionDescription, razor bool) string {
+	var args []string
+	if function.Arguments != "" {
+		for i, argument := range strings.Split(function.Arguments, ", ") {
+			if fields := strings.Fields(argument); len(fields) > 0 {
+				args = append(args, fmt.Sprintf("${%d:%s}", i+1, fields[0]))
+			}
+		}
+	}
+	if razor {
+		return fmt.Sprintf("%s(%s)", function.Name, strings.Join(args, ", "))
+	}
+	return strings.Join(append([]string{function.Name}, args...), " ")
+}
+
+// Returns the signature, the description and the examples of the function under the cursor.
+func (s *lspServer) hover(line string, offset int) interface{} {
+	function := s.function(wordAt(line, offset))
+	if function == nil {
+		return nil
+	}
+	value := fmt.Sprintf("```\n%s\n```", function.Signature)
+	if function.Description != "" {
+		value += "\n\n" + function.Description
+	}
+	if len(function.Aliases) > 0 {
+		value += fmt.Sprintf("\n\nAliases: %s", strings.Join(function.Aliases, ", "))
+	}
+	for _, example := range function.Examples {
+		code := example.Razor
+		if code == "" {
+			code = example.Template
+		}
+		value += "\n\nExample:\n```\n" + strings.TrimSpace(code) + "\n```"
+		if example.Result != "" {
+			value += "\nResult: `" + example.Result + "`"
+		}
+	}
+	return map[string]interface{}{"contents": map[string]interface{}{"kind": "markdown", "value": value}}
+}
+
+// Returns the function corresponding to the name (that could be prefixed by its namespace).
+func (s *lspServer) function(name string) *template.FunctionDescription {
+	namespace, function := collections.Split2(name, ".")
+	if function == "" {
+		namespace, function = "", name
+	}
+	for i := range s.functions {
+		candidate := &s.functions[i]
+		if namespace == "" && candidate.Name == function {
+			return candidate
+		}
+		// The first letter of a namespaced function could be capitalized (i.e. math.Pow for math.pow)
+		if namespace != "" && candidate.Namespace == namespace && strings.EqualFold(candidate.Name, function) && candidate.Name[1:] == function[1:] {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// Returns the locations where the template named by the string under the cursor is defined. The opened documents
+// (starting with the current one) are searched first, then the files defining the templates of the extensions.
+func (s *lspServer) definition(uri, line string, offset int) []lspLocation {
+	name := quotedAt(line, offset)
+	result := make([]lspLocation, 0)
+	if name == "" {
+		return result
+	}
+	reDefine := regexp.MustCompile(`(?:define|block)\s*\(?\s*"` + regexp.QuoteMeta(name) + `"`)
+	found := make(map[lspLocation]bool)
+	add := func(uri, content string) bool {
+		var added bool
+		for _, match := range reDefine.FindAllStringIndex(content, -1) {
+			before := content[:match[0]]
+			lineStart := strings.LastIndex(before, "\n") + 1
+			location := lspLocation{URI: uri}
+			location.Range.Start = lspPosition{strings.Count(before, "\n"), utf16Length(content[lineStart:match[0]])}
+			location.Range.End = location.Range.Start
+			location.Range.End.Character += utf16Length(content[match[0]:match[1]])
+			if !found[location] {
+				found[location], added = true, true
+				result = append(result, location)
+			}
+		}
+		return added
+	}
+
+	add(uri, s.documents[uri])
+	others := make([]string, 0, len(s.documents))
+	for other := range s.documents {
+		if other != uri {
+			others = append(others, other)
+		}
+	}
+	sort.Strings(others)
+	for _, other := range others {
+		add(other, s.documents[other])
+	}
+	for _, tpl := range s.templates {
+		if tpl.Name != name {
+			continue
+		}
+		file := tpl.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(s.folder, file)
+		}
+		uri := pathToURI(file)
+		if _, open := s.documents[uri]; open {
+			continue
+		}
+		content, _ := os.ReadFile(file)
+		if !add(uri, string(content)) {
+			// We are unable to locate the definition in the file, so we simply refer to the file
+			if location := (lspLocation{URI: uri}); !found[location] {
+				found[location] = true
+				result = append(result, location)
+			}
+		}
+	}
+	return result
+}
+
+// Returns the line at the position and the position converted into a byte offset within this line.
+func lineAt(content string, position lspPosition) (string, int) {
+	lines := strings.Split(content, "\n")
+	if position.Line < 0 || position.Line >= len(lines) {
+		return "", 0
+	}
+	line := strings.TrimSuffix(lines[position.Line], "\r")
+	offset, units := 0, 0
+	for offset < len(line) && units < position.Character {
+		r, size := utf8.DecodeRuneInString(line[offset:])
+		offset, units = offset+size, units+len(utf16.Encode([]rune{r}))
+	}
+	return line, offset
+}
+
+func utf16Length(text string) int { return len(utf16.Encode([]rune(text))) }
+
+func isLspIdentifier(c byte) bool {
+	return c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= utf8.RuneSelf
+}
+
+// Returns the identifier (that could contain dots) under the cursor.
+func wordAt(line string, offset int) string {
+	start, end := offset, offset
+	for start > 0 && isLspIdentifier(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isLspIdentifier(line[end]) {
+		end++
+	}
+	return strings.Trim(line[start:end], ".")
+}
+
+// Returns the content of the double quoted string under the cursor.
+func quotedAt(line string, offset int) string {
+	if strings.Count(line[:offset], `"`)%2 == 0 {
+		return ""
+	}
+	start := strings.LastIndex(line[:offset], `"`) + 1
+	end := strings.Index(line[offset:], `"`)
+	if end < 0 {
+		return ""
+	}
+	return line[start : offset+end]
+}
+
+func uriToPath(uri string) string {
+	if parsed, err := url.Parse(uri); err == nil && parsed.Scheme == "file" {
+		return filepath.FromSlash(parsed.Path)
+	}
+	return uri
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/stretchr/testify/assert"
+)
+
+// Run a scripted LSP session and return the messages sent by the server.
+func lspSession(t *testing.T, tpl *template.Template, folder string, messages ...map[string]interface{}) (responses map[int]interface{}, notifications []map[string]interface{}) {
+	var in, out bytes.Buffer
+	for _, message := range messages {
+		message["jsonrpc"] = "2.0"
+		content, err := json.Marshal(message)
+		assert.NoError(t, err)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	}
+	assert.NoError(t, runLsp(tpl, folder, &in, &out))
+
+	responses = make(map[int]interface{})
+	reader := bufio.NewReader(&out)
+	for {
+		content, err := readLspMessage(reader)
+		if err != nil {
+			break
+		}
+		var message map[string]interface{}
+		assert.NoError(t, json.Unmarshal(content, &message))
+		if id, isResponse := message["id"].(float64); isResponse {
+			responses[int(id)] = message["result"]
+			if message["error"] != nil {
+				responses[int(id)] = message["error"]
+			}
+		} else {
+			notifications = append(notifications, message)
+		}
+	}
+	return
+}
+
+func lspPositionParams(uri string, line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	}
+}
+
+func TestLsp(t *testing.T) {
+	folder := t.TempDir()
+	extension := filepath.Join(folder, "ext.gte")
+	assert.NoError(t, os.WriteFile(extension, []byte("Greetings\n@define(\"greeting\")Hello @.name@end\n"), 0644))
+	tpl := template.MustNewTemplate(folder, nil, "", nil)
+
+	uri := pathToURI(filepath.Join(folder, "test.gt"))
+	content := strings.Join([]string{
+		`@trunc(2, "abc")`,
+		`{{ if }}`,
+		`@include("greeting", data)`,
+		`{{ sprig.trunc }}`,
+		`@spli`,
+	}, "\n")
+	responses, notifications := lspSession(t, tpl, folder,
+		map[string]interface{}{"id": 1, "method": "initialize", "params": map[string]interface{}{}},
+		map[string]interface{}{"method": "initialized", "params": map[string]interface{}{}},
+		map[string]interface{}{"method": "textDocument/didOpen", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "languageId": "gotemplate", "version": 1, "text": content},
+		}},
+		map[string]interface{}{"id": 2, "method": "textDocument/hover", "params": lspPositionParams(uri, 0, 3)},
+		map[string]interface{}{"id": 3, "method": "textDocument/definition", "params": lspPositionParams(uri, 2, 12)},
+		map[string]interface{}{"id": 4, "method": "textDocument/completion", "params": lspPositionParams(uri, 3, 14)},
+		map[string]interface{}{"id": 5, "method": "textDocument/completion", "params": lspPositionParams(uri, 4, 5)},
+		map[string]interface{}{"id": 6, "method": "textDocument/hover", "params": lspPositionParams(uri, 1, 0)},
+		map[string]interface{}{"id": 7, "method": "unknown"},
+		map[string]interface{}{"method": "textDocument/didClose", "params": map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri}}},
+		map[string]interface{}{"id": 8, "method": "shutdown"},
+		map[string]interface{}{"method": "exit"},
+	)
+
+	capabilities := responses[1].(map[string]interface{})["capabilities"].(map[string]interface{})
+	assert.Equal(t, true, capabilities["hoverProvider"])
+	assert.Equal(t, true, capabilities["definitionProvider"])
+
+	if assert.Len(t, notifications, 2) {
+		assert.Equal(t, "textDocument/publishDiagnostics", notifications[0]["method"])
+		diagnostics := notifications[0]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+		if assert.Len(t, diagnostics, 1) {
+			assert.Equal(t, map[string]interface{}{
+				"range":    map[string]interface{}{"start": map[string]interface{}{"line": 1.0, "character": 0.0}, "end": map[string]interface{}{"line": 1.0, "character": 8.0}},
+				"severity": 1.0,
+				"source":   "gotemplate",
+				"message":  "missing value for if",
+			}, diagnostics[0])
+		}
+		assert.Empty(t, notifications[1]["params"].(map[string]interface{})["diagnostics"], "Diagnostics are cleared on close")
+	}
+
+	hover := responses[2].(map[string]interface{})["contents"].(map[string]interface{})["value"].(string)
+	assert.True(t, strings.HasPrefix(hover, "```\ntrunc(x interface{}) interface{}\n```\n\nReturns the integer value of x."), hover)
+
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"uri":   pathToURI(extension),
+		"range": map[string]interface{}{"start": map[string]interface{}{"line": 1.0, "character": 1.0}, "end": map[string]interface{}{"line": 1.0, "character": 18.0}},
+	}}, responses[3])
+
+	var labels []string
+	for _, item := range responses[4].([]interface{}) {
+		labels = append(labels, item.(map[string]interface{})["label"].(string))
+	}
+	assert.Equal(t, []string{"truncSprig"}, labels, "Only the functions of the namespace are proposed")
+	assert.Equal(t, "truncSprig ${1:length} ${2:str}", responses[4].([]interface{})[0].(map[string]interface{})["insertText"], "Go template syntax")
+
+	var razor []string
+	for _, item := range responses[5].([]interface{}) {
+		razor = append(razor, item.(map[string]interface{})["insertText"].(string))
+	}
+	assert.Contains(t, razor, "split(${1:separator}, ${2:str})", "Razor syntax")
+
+	assert.Nil(t, responses[6], "No hover outside of a function")
+	assert.Equal(t, map[string]interface{}{"code": -32601.0, "message": "method unknown is not supported"}, responses[7])
+	assert.Contains(t, responses, 8)
+}
+
+func TestLspExitWithoutShutdown(t *testing.T) {
+	var out bytes.Buffer
+	exit := `{"jsonrpc":"2.0","method":"exit"}`
+	input := fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(exit), exit)
+	err := runLsp(template.MustNewTemplate(t.TempDir(), nil, "", nil), "", strings.NewReader(input), &out)
+	assert.EqualError(t, err, "exit requested before shutdown")
+}
@@ -2,13 +2,17 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
 	"regexp"
 	"runtime/debug"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/coveooss/gotemplate/v3/collections"
 	"github.com/coveooss/gotemplate/v3/hcl"
@@ -29,6 +33,7 @@ var tempFolder = errors.Must(os.MkdirTemp("", "gotemplate-")).(string)
 
 const (
 	envDisableStdinCheck = "GOTEMPLATE_NO_STDIN"
+	exitCodeDrift        = 2 // Exit code returned in diff mode when at least one file would be modified
 )
 const description = `
 An extended template processor for go.
@@ -36,16 +41,144 @@ An extended template processor for go.
 See: https://coveooss.github.io/gotemplate for complete documentation.
 `
 
-func runGotemplate() (exitCode int) {
-	defer func() {
-		if rec := recover(); rec != nil {
-			errPrintf(color.RedString("Recovered %v\n"), rec)
-			debug.PrintStack()
-			exitCode = -1
-		}
-		cleanup()
-	}()
+// The values accepted by the type flag.
+var typeModes = []string{"Hcl", "h", "hcl", "H", "HCL", "Json", "j", "json", "J", "JSON", "Yaml", "Yml", "y", "yml", "yaml", "Y", "YML", "YAML"}
 
+// The help of the flags shared by several commands.
+const (
+	delimitersHelp          = "Define the default delimiters for go template (separate the left, right and razor delimiters by a comma)"
+	importHelp              = "Import variables files (could be any of YAML, JSON or HCL format)"
+	importIfExistHelp       = "Import variables files (do not consider missing file as an error)"
+	varHelp                 = "Import named variables (if value is a file, the content is loaded)"
+	typeHelp                = "Force the type used for the main context (Json, Yaml, Hcl)"
+	sourceHelp              = "Specify a source folder (default to the current folder)"
+	targetHelp              = "Specify a target folder (default to source folder)"
+	followSymLinksHelp      = "Follow the symbolic links while using the recursive option"
+	ignoreMissingImportHelp = "Exit with code 0 even if import does not exist"
+	ignoreMissingSourceHelp = "Exit with code 0 even if source does not exist"
+	ignoreRazorHelp         = "Do not consider the list of excluded Razor name as razor expression"
+	acceptNoValueHelp       = "Do not consider rendering <no value> as an error"
+	strictErrorHelp         = "Consider error encountered in any file as real error"
+	sandboxHelp             = "Disable the functions with side effects (exec, run, save, httpGet, exit, ...) and only allow reading files from the sandbox roots"
+	sandboxRootHelp         = "Folder from which the templates are allowed to read files in sandbox mode (default to the current and the source folders)"
+	deterministicHelp       = "Use a fixed instant for the time functions and a seeded random source for the random functions (see GOTEMPLATE_NOW and GOTEMPLATE_SEED)"
+	preferBuiltinsHelp      = "Use the built-in functions instead of the functions having the same name defined by the extensions and the plugins (the other implementation remains available through its namespace, i.e. ext.trunc)"
+	errorOutputHelp         = "File where the json or sarif report is written (default to stderr)"
+)
+
+// The command line of gotemplate. The flags of the commands sharing the same behavior are bound to the same fields.
+type commandLine struct {
+	app             *kingpin.Application
+	configFlag      *kingpin.FlagClause
+	completionModel *kingpin.ApplicationModel
+	enums           completionEnums
+	command         string
+	options         []bool
+
+	colorIsSet           bool
+	colorEnabled         *bool
+	getVersion           *bool
+	templateLogLevel     *string
+	internalLogLevel     *string
+	logFilePath          *string
+	templateLogFileLevel *string
+	internalLogFileLevel *string
+	configFile           *string
+	printEffectiveConfig *bool
+	plugins              *[]string
+	optionsOff           *bool
+
+	run                 *kingpin.CmdClause
+	delimiters          *string
+	varFiles            *[]string
+	varFilesIfExist     *[]string
+	namedVars           *[]string
+	typeMode            *string
+	includePatterns     *[]string
+	excludedPatterns    *[]string
+	overwrite           *bool
+	substitutes         *[]string
+	removeEmptyLines    *bool
+	recursive           *bool
+	recursionDepth      *int
+	sourceFolder        *string
+	targetFolder        *string
+	forceStdin          *bool
+	followSymLinks      *bool
+	printOutput         *bool
+	disableRender       *bool
+	watchMode           *bool
+	watchInterval       *time.Duration
+	diffMode            *bool
+	writeManifest       *bool
+	errorFormat         *string
+	errorOutput         *string
+	jobs                *int
+	acceptNoValue       *bool
+	strictError         *bool
+	strictAssignations  *string
+	ignoreMissingImport *bool
+	ignoreMissingSource *bool
+	ignoreMissingPaths  *bool
+	ignoreRazor         *[]string
+	sandbox             *bool
+	sandboxRoots        *[]string
+	deterministic       *bool
+	preferBuiltins      *bool
+	timeout             *time.Duration
+	templateTimeout     *time.Duration
+	templates           *[]string
+
+	list          *kingpin.CmdClause
+	listFunctions *bool
+	listTemplates *bool
+	listLong      *bool
+	listAll       *bool
+	listCategory  *bool
+	listFormat    *string
+	listFilters   *[]string
+
+	check *kingpin.CmdClause
+
+	clean        *kingpin.CmdClause
+	cleanAll     *bool
+	cleanRestore *bool
+	cleanForce   *bool
+	cleanDryRun  *bool
+
+	testCommand *kingpin.CmdClause
+	testUpdate  *bool
+
+	graph       *kingpin.CmdClause
+	graphFormat *string
+
+	serve        *kingpin.CmdClause
+	serveAddress *string
+
+	contextCommand *kingpin.CmdClause
+	contextFormat  *string
+	contextExplain *bool
+
+	docs       *kingpin.CmdClause
+	docsFormat *string
+	docsOutput *string
+
+	completion      *kingpin.CmdClause
+	completionShell *string
+
+	repl *kingpin.CmdClause
+	lsp  *kingpin.CmdClause
+
+	// The state resulting from the parsing of the command line
+	optionsSet      template.OptionsSet
+	parseExtensions bool
+	manifest        *template.Manifest
+	workingFolder   string
+	interactive     bool
+}
+
+// Declares the application, its commands and their flags.
+func newCommandLine() *commandLine {
 	app := kingpin.New(path.Base(os.Args[0]), description).AutoShortcut().DefaultEnvars().InitOnlyOnce().UsageWriter(os.Stdout)
 	app.DeleteFlag("help")
 	app.DeleteFlag("help-long")
@@ -53,52 +186,138 @@ func runGotemplate() (exitCode int) {
 	app.HelpFlag.Bool()
 	kingpin.CommandLine = app
 
-	var (
-		colorIsSet           bool
-		colorEnabled         = app.Flag("color", "Force rendering of colors event if output is redirected").IsSetByUser(&colorIsSet).Bool()
-		getVersion           = app.Flag("version", "Get the current version of gotemplate").Short('v').NoEnvar().Bool()
-		templateLogLevel     = app.Flag("template-log-level", "Set the template logging level. Accepted values: "+multilogger.AcceptedLevelsString()).Default(logrus.InfoLevel).PlaceHolder("level").String()
-		internalLogLevel     = app.Flag("internal-log-level", "Set the internal logging level. Accepted values: "+multilogger.AcceptedLevelsString()).Default(logrus.WarnLevel).PlaceHolder("level").Short('L').Alias("log-level").String()
-		logFilePath          = app.Flag("internal-log-file-path", "Set a file where verbose logs should be written").PlaceHolder("path").Short('F').String()
-		templateLogFileLevel = app.Flag("template-log-file-level", "Set the template logging level for the verbose logs file").Default(logrus.TraceLevel).PlaceHolder("level").String()
-		internalLogFileLevel = app.Flag("internal-log-file-level", "Set the internal logging level for the verbose logs file").Default(logrus.DebugLevel).PlaceHolder("level").String()
-
-		run                 = app.Command("run", "").Default()
-		delimiters          = run.Flag("delimiters", "Define the default delimiters for go template (separate the left, right and razor delimiters by a comma)").Alias("del").PlaceHolder("{{,}},@").String()
-		varFiles            = run.Flag("import", "Import variables files (could be any of YAML, JSON or HCL format)").PlaceHolder("file").Short('i').Strings()
-		varFilesIfExist     = run.Flag("import-if-exist", "Import variables files (do not consider missing file as an error)").PlaceHolder("file").Strings()
-		namedVars           = run.Flag("var", "Import named variables (if value is a file, the content is loaded)").PlaceHolder("values").Short('V').Strings()
-		typeMode            = run.Flag("type", "Force the type used for the main context (Json, Yaml, Hcl)").Short('t').Enum("Hcl", "h", "hcl", "H", "HCL", "Json", "j", "json", "J", "JSON", "Yaml", "Yml", "y", "yml", "yaml", "Y", "YML", "YAML")
-		includePatterns     = run.Flag("patterns", "Additional patterns that should be processed by gotemplate").PlaceHolder("pattern").Short('p').Strings()
-		excludedPatterns    = run.Flag("exclude", "Exclude file patterns (comma separated) when applying gotemplate recursively").PlaceHolder("pattern").Short('e').Strings()
-		overwrite           = run.Flag("overwrite", "Overwrite file instead of renaming them if they exist (required only if source folder is the same as the target folder)").Short('o').Bool()
-		substitutes         = run.Flag("substitute", "Substitute text in the processed files by applying the regex substitute expression (format: /regex/substitution, the first character acts as separator like in sed, see: Go regexp)").PlaceHolder("exp").Short('s').Strings()
-		removeEmptyLines    = run.Flag("remove-empty-lines", "Remove empty lines from the result").Alias("remove-empty").Short('E').Bool()
-		recursive           = run.Flag("recursive", "Process all template files recursively").Short('r').Bool()
-		recursionDepth      = run.Flag("recursion-depth", "Process template files recursively specifying depth").Short('R').PlaceHolder("depth").Int()
-		sourceFolder        = run.Flag("source", "Specify a source folder (default to the current folder)").PlaceHolder("folder").String()
-		targetFolder        = run.Flag("target", "Specify a target folder (default to source folder)").PlaceHolder("folder").String()
-		forceStdin          = run.Flag("stdin", "Force read of the standard input to get a template definition (useful only if GOTEMPLATE_NO_STDIN is set)").Short('I').Bool()
-		followSymLinks      = run.Flag("follow-symlinks", "Follow the symbolic links while using the recursive option").Short('f').Bool()
-		printOutput         = run.Flag("print", "Output the result directly to stdout").Short('P').Bool()
-		disableRender       = run.Flag("disable", "Disable go template rendering (used to view razor conversion)").Short('d').Bool()
-		acceptNoValue       = run.Flag("accept-no-value", "Do not consider rendering <no value> as an error").Alias("no-value").Envar(template.EnvAcceptNoValue).Bool()
-		strictError         = run.Flag("strict-error-validation", "Consider error encountered in any file as real error").Alias("strict").Envar(template.EnvStrictErrorCheck).Short('S').Bool()
-		strictAssignations  = run.Flag("strict-assignations-validation", "Enforce strict assignation validation on global variables").Default("warning").Enum("on", "off", "warning")
-		ignoreMissingImport = run.Flag("ignore-missing-import", "Exit with code 0 even if import does not exist").Bool()
-		ignoreMissingSource = run.Flag("ignore-missing-source", "Exit with code 0 even if source does not exist").Bool()
-		ignoreMissingPaths  = run.Flag("ignore-missing-paths", "Exit with code 0 even if import or source do not exist").Bool()
-		ignoreRazor         = run.Flag("ignore-razor", "Do not consider the list of excluded Razor name as razor expression").PlaceHolder("regex").NoEnvar().Strings()
-		templates           = run.Arg("templates", "Template files or commands to process").Strings()
-
-		list          = app.Command("list", "Get detailed help on gotemplate functions").NoAutoShortcut()
-		listFunctions = list.Flag("functions", "Get detailed help on function").Short('f').NoEnvar().Bool()
-		listTemplates = list.Flag("templates", "List the available templates").Short('t').NoEnvar().Bool()
-		listLong      = list.Flag("long", "Get detailed list").Short('l').NoEnvar().Bool()
-		listAll       = list.Flag("all", "List all").Short('a').NoEnvar().Bool()
-		listCategory  = list.Flag("category", "Group functions by category").Short('c').NoEnvar().Bool()
-		listFilters   = list.Arg("filters", "List only functions that contains one of the filter").Strings()
-	)
+	c := &commandLine{app: app, enums: make(completionEnums)}
+	c.colorEnabled = app.Flag("color", "Force rendering of colors event if output is redirected").IsSetByUser(&c.colorIsSet).Bool()
+	c.getVersion = app.Flag("version", "Get the current version of gotemplate").Short('v').NoEnvar().Bool()
+	c.templateLogLevel = app.Flag("template-log-level", "Set the template logging level. Accepted values: "+multilogger.AcceptedLevelsString()).Default(logrus.InfoLevel).PlaceHolder("level").String()
+	c.internalLogLevel = app.Flag("internal-log-level", "Set the internal logging level. Accepted values: "+multilogger.AcceptedLevelsString()).Default(logrus.WarnLevel).PlaceHolder("level").Short('L').Alias("log-level").String()
+	c.logFilePath = app.Flag("internal-log-file-path", "Set a file where verbose logs should be written").PlaceHolder("path").Short('F').String()
+	c.templateLogFileLevel = app.Flag("template-log-file-level", "Set the template logging level for the verbose logs file").Default(logrus.TraceLevel).PlaceHolder("level").String()
+	c.internalLogFileLevel = app.Flag("internal-log-file-level", "Set the internal logging level for the verbose logs file").Default(logrus.DebugLevel).PlaceHolder("level").String()
+	c.configFlag = app.Flag("config", fmt.Sprintf("Specify the configuration file used to define the default flags values (default to %s.yaml found in the source folder or its parents)", configFileName)).PlaceHolder("file").NoAutoShortcut()
+	c.configFile = c.configFlag.String()
+	c.printEffectiveConfig = app.Flag("print-config", "Print the effective configuration (configuration file, environment variables and flags) as YAML").NoEnvar().NoAutoShortcut().Bool()
+	c.plugins = app.Flag("plugin", "Command line of a plugin providing additional functions through JSON-RPC on its standard input and output (could be repeated, the arguments containing spaces must be quoted)").Envar(template.EnvPlugins).PlaceHolder("command").NoAutoShortcut().Strings()
+
+	run := app.Command("run", "").Default()
+	c.run = run
+	c.delimiters = run.Flag("delimiters", delimitersHelp).Alias("del").PlaceHolder("{{,}},@").String()
+	c.varFiles = run.Flag("import", importHelp).PlaceHolder("file").Short('i').Strings()
+	c.varFilesIfExist = run.Flag("import-if-exist", importIfExistHelp).PlaceHolder("file").Strings()
+	c.namedVars = run.Flag("var", varHelp).PlaceHolder("values").Short('V').Strings()
+	c.typeMode = c.enumFlag(run.Flag("type", typeHelp).Short('t'), nil, typeModes...)
+	c.includePatterns = run.Flag("patterns", "Additional patterns that should be processed by gotemplate").PlaceHolder("pattern").Short('p').Strings()
+	c.excludedPatterns = run.Flag("exclude", "Exclude file patterns (comma separated) when applying gotemplate recursively").PlaceHolder("pattern").Short('e').Strings()
+	c.overwrite = run.Flag("overwrite", "Overwrite file instead of renaming them if they exist (required only if source folder is the same as the target folder)").Short('o').Bool()
+	c.substitutes = run.Flag("substitute", "Substitute text in the processed files by applying the regex substitute expression (format: /regex/substitution, the first character acts as separator like in sed, see: Go regexp)").PlaceHolder("exp").Short('s').Strings()
+	c.removeEmptyLines = run.Flag("remove-empty-lines", "Remove empty lines from the result").Alias("remove-empty").Short('E').Bool()
+	c.recursive = run.Flag("recursive", "Process all template files recursively").Short('r').Bool()
+	c.recursionDepth = run.Flag("recursion-depth", "Process template files recursively specifying depth").Short('R').PlaceHolder("depth").Int()
+	c.sourceFolder = run.Flag("source", sourceHelp).PlaceHolder("folder").String()
+	c.targetFolder = run.Flag("target", targetHelp).PlaceHolder("folder").String()
+	c.forceStdin = run.Flag("stdin", "Force read of the standard input to get a template definition (useful only if GOTEMPLATE_NO_STDIN is set)").Short('I').Bool()
+	c.followSymLinks = run.Flag("follow-symlinks", followSymLinksHelp).Short('f').Bool()
+	c.printOutput = run.Flag("print", "Output the result directly to stdout").Short('P').Bool()
+	c.disableRender = run.Flag("disable", "Disable go template rendering (used to view razor conversion)").Short('d').Bool()
+	c.watchMode = run.Flag("watch", "Watch the templates, the imported files and the extensions and render the affected templates again when they change").Short('w').Bool()
+	c.watchInterval = run.Flag("watch-interval", "Interval between the checks for changes in watch mode").Default("500ms").PlaceHolder("duration").Duration()
+	c.diffMode = run.Flag("diff", fmt.Sprintf("Print the differences between the rendered templates and the existing files instead of writing them (exit with code %d if any file would change)", exitCodeDrift)).Alias("dry-run").NoAutoShortcut().Bool()
+	c.writeManifest = run.Flag("manifest", fmt.Sprintf("Register the generated files in %s of the target folder (required by the clean command)", template.ManifestFileName)).Bool()
+	c.errorFormat = c.enumFlag(run.Flag("error-format", fmt.Sprintf("Format used to report the template errors and warnings (%[2]s or %[3]s reports are printed on stderr, including warnings)", errorFormatText, errorFormatJSON, errorFormatSARIF)).Default(errorFormatText), nil, errorFormatText, errorFormatJSON, errorFormatSARIF)
+	c.errorOutput = run.Flag("error-output", errorOutputHelp).PlaceHolder("file").NoAutoShortcut().String()
+	c.jobs = run.Flag("jobs", "Number of templates rendered concurrently (the output order is preserved)").Short('j').Default("1").PlaceHolder("count").Int()
+	c.acceptNoValue = run.Flag("accept-no-value", acceptNoValueHelp).Alias("no-value").Envar(template.EnvAcceptNoValue).Bool()
+	c.strictError = run.Flag("strict-error-validation", strictErrorHelp).Alias("strict").Envar(template.EnvStrictErrorCheck).Short('S').Bool()
+	c.strictAssignations = c.enumFlag(run.Flag("strict-assignations-validation", "Enforce strict assignation validation on global variables").Default("warning"), nil, "on", "off", "warning")
+	c.ignoreMissingImport = run.Flag("ignore-missing-import", ignoreMissingImportHelp).Bool()
+	c.ignoreMissingSource = run.Flag("ignore-missing-source", ignoreMissingSourceHelp).Bool()
+	c.ignoreMissingPaths = run.Flag("ignore-missing-paths", "Exit with code 0 even if import or source do not exist").Bool()
+	c.ignoreRazor = run.Flag("ignore-razor", ignoreRazorHelp).PlaceHolder("regex").NoEnvar().Strings()
+	c.sandbox = run.Flag("sandbox", sandboxHelp).NoAutoShortcut().Bool()
+	c.sandboxRoots = run.Flag("sandbox-root", sandboxRootHelp).NoAutoShortcut().PlaceHolder("folder").Strings()
+	c.deterministic = run.Flag("deterministic", deterministicHelp).NoAutoShortcut().Bool()
+	c.preferBuiltins = run.Flag("prefer-builtins", preferBuiltinsHelp).NoAutoShortcut().Bool()
+	c.timeout = run.Flag("timeout", "Maximum duration of the whole processing (i.e. 30s, 5m), the running commands and requests are interrupted when it expires").NoAutoShortcut().PlaceHolder("duration").Duration()
+	c.templateTimeout = run.Flag("template-timeout", "Maximum duration of the processing of each template").NoAutoShortcut().PlaceHolder("duration").Duration()
+	c.templates = run.Arg("templates", "Template files or commands to process").Strings()
+
+	c.list = app.Command("list", "Get detailed help on gotemplate functions").NoAutoShortcut()
+	c.listFunctions = c.list.Flag("functions", "Get detailed help on function").Short('f').NoEnvar().Bool()
+	c.listTemplates = c.list.Flag("templates", "List the available templates").Short('t').NoEnvar().Bool()
+	c.listLong = c.list.Flag("long", "Get detailed list").Short('l').NoEnvar().Bool()
+	c.listAll = c.list.Flag("all", "List all").Short('a').NoEnvar().Bool()
+	c.listCategory = c.list.Flag("category", "Group functions by category").Short('c').NoEnvar().Bool()
+	c.listFormat = c.enumFlag(c.list.Flag("format", "Output format (json and yaml include all the details of the functions and templates)").Default(listFormatText), nil, listFormatText, listFormatJSON, listFormatYAML)
+	c.listFilters = c.list.Arg("filters", "List only functions that contains one of the filter").Strings()
+	// The list command must show the functions that are actually used
+	c.list.Flag("prefer-builtins", preferBuiltinsHelp).NoAutoShortcut().BoolVar(c.preferBuiltins)
+
+	c.check = app.Command("check", "Parse the templates (after razor conversion) without executing them").NoAutoShortcut()
+	c.addParsingFlags(c.check)
+	c.addSelectionFlags(c.check, "checked")
+	c.check.Flag("substitute", "Substitute text in the checked files by applying the regex substitute expression (format: /regex/substitution)").PlaceHolder("exp").Short('s').StringsVar(c.substitutes)
+	c.enumFlag(c.check.Flag("error-format", fmt.Sprintf("Format used to report the template errors (%s, %s or %s)", errorFormatText, errorFormatJSON, errorFormatSARIF)).Default(errorFormatText), c.errorFormat, errorFormatText, errorFormatJSON, errorFormatSARIF)
+	c.check.Flag("error-output", errorOutputHelp).PlaceHolder("file").NoAutoShortcut().StringVar(c.errorOutput)
+	c.check.Arg("templates", "Template files or commands to check").StringsVar(c.templates)
+
+	c.clean = app.Command("clean", fmt.Sprintf("Remove the generated files (registered in %s by run --manifest) that are not produced anymore", template.ManifestFileName)).NoAutoShortcut()
+	c.cleanAll = c.clean.Flag("all", "Remove all generated files, even if they are still produced").Short('a').NoEnvar().Bool()
+	c.cleanRestore = c.clean.Flag("restore", "Move the .original files back in place").NoEnvar().Bool()
+	c.cleanForce = c.clean.Flag("force", "Remove the generated files even if they have been modified since their generation").NoEnvar().Bool()
+	c.cleanDryRun = c.clean.Flag("dry-run", "Only print the actions that would be done").Alias("diff").NoEnvar().Bool()
+	// The clean command must be able to locate the manifest in the target folder
+	c.clean.Flag("source", sourceHelp).PlaceHolder("folder").StringVar(c.sourceFolder)
+	c.clean.Flag("target", targetHelp).PlaceHolder("folder").StringVar(c.targetFolder)
+
+	c.testCommand = app.Command("test", fmt.Sprintf("Render the templates and compare the results with the golden files (%s and %s files next to the template)", goldenRazorExt, goldenRenderedExt)).NoAutoShortcut()
+	c.testUpdate = c.testCommand.Flag("update", "Rewrite the golden files with the current results (create them if they do not exist)").Short('u').NoEnvar().Bool()
+	c.addParsingFlags(c.testCommand)
+	c.addContextFlags(c.testCommand)
+	c.addSelectionFlags(c.testCommand, "tested")
+	c.addExecutionFlags(c.testCommand)
+	c.testCommand.Flag("accept-no-value", acceptNoValueHelp).Alias("no-value").Envar(template.EnvAcceptNoValue).BoolVar(c.acceptNoValue)
+	c.testCommand.Flag("strict-error-validation", strictErrorHelp).Alias("strict").Envar(template.EnvStrictErrorCheck).Short('S').BoolVar(c.strictError)
+	c.testCommand.Arg("templates", "Template files to test").StringsVar(c.templates)
+
+	c.graph = app.Command("graph", "Print the dependencies (sub-templates, data files, output files and commands) statically found in the templates").NoAutoShortcut()
+	c.graphFormat = c.enumFlag(c.graph.Flag("format", "Output format of the dependency graph").Default(graphFormatDOT), nil, graphFormatDOT, graphFormatJSON)
+	c.addParsingFlags(c.graph)
+	c.addSelectionFlags(c.graph, "analyzed")
+	c.graph.Arg("templates", "Template files or commands to analyze").StringsVar(c.templates)
+
+	c.serve = app.Command("serve", "Start an HTTP server rendering the templates posted to /render (the templates of the source folder are preloaded)").NoAutoShortcut()
+	c.serveAddress = c.serve.Flag("address", "Address (host:port) on which the server listens").Default("localhost:8080").PlaceHolder("host:port").String()
+	c.addParsingFlags(c.serve)
+	c.addContextFlags(c.serve)
+	c.addSelectionFlags(c.serve, "preloaded")
+	c.addExecutionFlags(c.serve)
+	c.serve.GetFlag("source").Help("Specify the folder containing the templates and the extensions to preload (default to the current folder)")
+	// The served templates come from the network, so they must explicitly be allowed to have side effects
+	c.serve.GetFlag("sandbox").Help(sandboxHelp + " (ON by default since the templates are received from the network, a configuration file found in the folders cannot disable it)").Default("true")
+
+	c.contextCommand = app.Command("context", "Print the context resulting from the imported files and variables").NoAutoShortcut()
+	c.contextFormat = c.enumFlag(c.contextCommand.Flag("format", "Output format of the context").Default(contextFormatYAML), nil, contextFormatJSON, contextFormatYAML, contextFormatHCL)
+	c.contextExplain = c.contextCommand.Flag("explain", "Annotate each top level key with the file or flag that set it and the values it overrode").NoEnvar().Bool()
+	c.addContextFlags(c.contextCommand)
+	c.enumFlag(c.contextCommand.Flag("type", typeHelp).Short('t'), c.typeMode, typeModes...)
+
+	c.docs = app.Command("docs", "Generate the reference documentation of the functions (including the ones defined in the extensions) and of the objects methods").NoAutoShortcut()
+	c.docsFormat = c.enumFlag(c.docs.Flag("format", "Output format of the documentation").Default(docsFormatMarkdown), nil, docsFormatMarkdown, docsFormatJSON, docsFormatHTML)
+	c.docsOutput = c.docs.Flag("output", "Folder where the documentation is generated").Short('o').Default(".").PlaceHolder("folder").String()
+
+	c.completion = app.Command("completion", "Print the shell completion script (i.e. source <(gotemplate completion bash))").NoAutoShortcut()
+	shell := c.completion.Arg("shell", "The shell for which the completion script is generated").Required()
+	c.completionShell = shell.Enum(completionBash, completionZsh, completionFish)
+	c.enums[shell.Model().Value] = []string{completionBash, completionZsh, completionFish}
+
+	c.repl = app.Command("repl", "Evaluate razor and go template expressions interactively (the context is kept between lines)").NoAutoShortcut()
+	c.addParsingFlags(c.repl)
+	c.addContextFlags(c.repl)
+	c.addExecutionFlags(c.repl)
+
+	c.lsp = app.Command("lsp", "Start a language server (LSP) on the standard input and output providing diagnostics, completion, hover and go to definition for the template files").NoAutoShortcut()
+	c.addParsingFlags(c.lsp)
+	c.addExecutionFlags(c.lsp)
 
 	loadAllAddins := true
 	for i := range os.Args {
@@ -112,15 +331,146 @@ func runGotemplate() (exitCode int) {
 	}
 
 	// Set the options for the available options (most of them are on by default)
-	optionsOff := app.Flag("base", "Turn off all addons (they could then be enabled explicitly)").NoAutoShortcut().Bool()
-	options := make([]bool, template.OptionOnByDefaultCount)
-	for i := range options {
+	c.optionsOff = app.Flag("base", "Turn off all addons (they could then be enabled explicitly)").NoAutoShortcut().Bool()
+	c.options = make([]bool, template.OptionOnByDefaultCount)
+	for i := range c.options {
 		opt := template.Options(i)
 		optName := strings.ToLower(fmt.Sprint(opt))
-		app.Flag(optName, fmt.Sprintf("%v Addon (ON by default)", opt)).NoAutoShortcut().Default(loadAllAddins).BoolVar(&options[i])
+		app.Flag(optName, fmt.Sprintf("%v Addon (ON by default)", opt)).NoAutoShortcut().Default(loadAllAddins).BoolVar(&c.options[i])
 	}
 	app.GetFlag("extension").Alias("ext")
 
+	// The completion script is generated from the flags defined so far (before the automatic shortcuts are added)
+	c.completionModel = app.Model()
+	return c
+}
+
+// Declares a flag accepting one of the values (a new target is allocated if none is supplied). Since kingpin does not
+// expose the values of the enum flags, they are registered to be proposed by the completion script.
+func (c *commandLine) enumFlag(flag *kingpin.FlagClause, target *string, values ...string) *string {
+	if target == nil {
+		target = new(string)
+	}
+	flag.EnumVar(target, values...)
+	c.enums[flag.Model().Value] = values
+	return target
+}
+
+// Adds the flags affecting the razor conversion of the templates (bound to the same variables as the run command).
+func (c *commandLine) addParsingFlags(cmd *kingpin.CmdClause) {
+	cmd.Flag("delimiters", delimitersHelp).Alias("del").PlaceHolder("{{,}},@").StringVar(c.delimiters)
+	cmd.Flag("ignore-razor", ignoreRazorHelp).PlaceHolder("regex").NoEnvar().StringsVar(c.ignoreRazor)
+}
+
+// Adds the flags defining the context of the templates (bound to the same variables as the run command).
+func (c *commandLine) addContextFlags(cmd *kingpin.CmdClause) {
+	cmd.Flag("import", importHelp).PlaceHolder("file").Short('i').StringsVar(c.varFiles)
+	cmd.Flag("import-if-exist", importIfExistHelp).PlaceHolder("file").StringsVar(c.varFilesIfExist)
+	cmd.Flag("var", varHelp).PlaceHolder("values").Short('V').StringsVar(c.namedVars)
+	cmd.Flag("ignore-missing-import", ignoreMissingImportHelp).BoolVar(c.ignoreMissingImport)
+}
+
+// Adds the flags selecting the template files in the source folder (bound to the same variables as the run command).
+// The action describes what the command does with the selected templates.
+func (c *commandLine) addSelectionFlags(cmd *kingpin.CmdClause, action string) {
+	cmd.Flag("patterns", fmt.Sprintf("Additional patterns of templates that should be %s", action)).PlaceHolder("pattern").Short('p').StringsVar(c.includePatterns)
+	cmd.Flag("exclude", fmt.Sprintf("Exclude file patterns (comma separated) of templates that should not be %s", action)).PlaceHolder("pattern").Short('e').StringsVar(c.excludedPatterns)
+	cmd.Flag("recursive", "Select all template files recursively").Short('r').BoolVar(c.recursive)
+	cmd.Flag("recursion-depth", "Select template files recursively specifying depth").Short('R').PlaceHolder("depth").IntVar(c.recursionDepth)
+	cmd.Flag("source", sourceHelp).PlaceHolder("folder").StringVar(c.sourceFolder)
+	cmd.Flag("follow-symlinks", followSymLinksHelp).Short('f').BoolVar(c.followSymLinks)
+	cmd.Flag("ignore-missing-source", ignoreMissingSourceHelp).BoolVar(c.ignoreMissingSource)
+}
+
+// Adds the flags restricting or changing the functions available to the templates (bound to the same variables as
+// the run command).
+func (c *commandLine) addExecutionFlags(cmd *kingpin.CmdClause) {
+	cmd.Flag("sandbox", sandboxHelp).NoAutoShortcut().BoolVar(c.sandbox)
+	cmd.Flag("sandbox-root", sandboxRootHelp).NoAutoShortcut().PlaceHolder("folder").StringsVar(c.sandboxRoots)
+	cmd.Flag("deterministic", deterministicHelp).NoAutoShortcut().BoolVar(c.deterministic)
+	cmd.Flag("prefer-builtins", preferBuiltinsHelp).NoAutoShortcut().BoolVar(c.preferBuiltins)
+}
+
+func runGotemplate() (exitCode int) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			errPrintf(color.RedString("Recovered %v\n"), rec)
+			debug.PrintStack()
+			exitCode = -1
+		}
+		cleanup()
+	}()
+
+	c := newCommandLine()
+	if exitCode, done := c.parse(); done {
+		return exitCode
+	}
+
+	// The commands that do not require a template
+	switch c.command {
+	case c.completion.FullCommand():
+		return c.runCompletion()
+	case c.clean.FullCommand():
+		return c.runClean()
+	case c.contextCommand.FullCommand():
+		return c.runContext()
+	}
+
+	t, exitCode := c.createTemplate()
+	if t == nil {
+		return
+	}
+
+	switch c.command {
+	case c.list.FullCommand():
+		return c.runList(t)
+	case c.docs.FullCommand():
+		return c.runDocs(t)
+	case c.repl.FullCommand():
+		return c.runRepl(t)
+	case c.lsp.FullCommand():
+		return c.runLsp(t)
+	case c.serve.FullCommand():
+		return c.runServe(t)
+	}
+
+	templates, explicitFiles, exitCode := c.selectTemplates(exitCode)
+	switch c.command {
+	case c.check.FullCommand():
+		return c.runCheck(t, templates, exitCode)
+	case c.testCommand.FullCommand():
+		return c.runTest(t, templates, exitCode)
+	case c.graph.FullCommand():
+		return c.runGraph(t, templates, exitCode)
+	}
+	return c.runTemplates(t, templates, explicitFiles, exitCode)
+}
+
+// Parses the arguments and applies the global settings, done is returned if there is nothing else to do.
+func (c *commandLine) parse() (exitCode int, done bool) {
+	app := c.app
+
+	// The configuration file defines the default values of the flags, so it must be applied before the actual parsing
+	preParsed := preParse(app, os.Args[1:], c.configFlag, c.run.GetFlag("source"))
+	if file := preParsed["config"]; file != "" {
+		*c.configFile = file
+	} else if source := preParsed["source"]; source != "" {
+		*c.configFile = findConfigFile(source)
+	} else {
+		*c.configFile = findConfigFile(".")
+	}
+	if *c.configFile != "" {
+		if err := applyConfig(app, *c.configFile); err != nil {
+			errors.Print(err)
+			return 1, true
+		}
+		if preParsed["config"] == "" {
+			// The served templates come from the network, so a configuration file found in the folders cannot disable
+			// the sandbox of the server (it must be disabled on the command line or in an explicit configuration file)
+			c.serve.GetFlag("sandbox").Default("true")
+		}
+	}
+
 	// Actually parse the arguments
 	command, err := kingpin.CommandLine.Parse(os.Args[1:])
 	if err != nil {
@@ -131,22 +481,22 @@ func runGotemplate() (exitCode int) {
 			os.Exit(0)
 		}
 	}
+	c.command = command
 
 	// We restore back the modified arguments
-	for i := range *templates {
-		(*templates)[i] = strings.TrimPrefix((*templates)[i], "#!!")
+	for i := range *c.templates {
+		(*c.templates)[i] = strings.TrimPrefix((*c.templates)[i], "#!!")
 	}
 
 	// Build the optionsSet
-	var optionsSet template.OptionsSet
-	if *optionsOff {
-		optionsSet = make(template.OptionsSet)
+	if *c.optionsOff {
+		c.optionsSet = make(template.OptionsSet)
 	} else {
-		optionsSet = template.DefaultOptions()
+		c.optionsSet = template.DefaultOptions()
 	}
 
 	// By default, we generate JSON list and dictionary
-	if mode := *typeMode; mode != "" {
+	if mode := *c.typeMode; mode != "" {
 		switch strings.ToUpper(mode[:1]) {
 		case "Y":
 			collections.SetListHelper(yaml.GenericListHelper)
@@ -163,16 +513,25 @@ func runGotemplate() (exitCode int) {
 		collections.SetDictionaryHelper(json.DictionaryHelper)
 	}
 
-	optionsSet[template.RenderingDisabled] = *disableRender
-	optionsSet[template.Overwrite] = *overwrite
-	optionsSet[template.OutputStdout] = *printOutput
-	optionsSet[template.AcceptNoValue] = *acceptNoValue
-	optionsSet[template.StrictErrorCheck] = *strictError
-	for i := range options {
-		optionsSet[template.Options(i)] = options[i]
+	c.optionsSet[template.RenderingDisabled] = *c.disableRender
+	c.optionsSet[template.Overwrite] = *c.overwrite
+	c.optionsSet[template.OutputStdout] = *c.printOutput && !*c.diffMode
+	c.optionsSet[template.DryRun] = *c.diffMode
+	c.optionsSet[template.Sandbox] = *c.sandbox
+	c.optionsSet[template.Deterministic] = *c.deterministic
+	c.optionsSet[template.PreferBuiltins] = *c.preferBuiltins
+	c.optionsSet[template.AcceptNoValue] = *c.acceptNoValue
+	c.optionsSet[template.StrictErrorCheck] = *c.strictError
+	for i := range c.options {
+		c.optionsSet[template.Options(i)] = c.options[i]
+	}
+	if command == c.check.FullCommand() {
+		// The checked templates must not execute anything, so the plugins are not started and the extensions are only parsed
+		c.optionsSet[template.Sandbox] = true
+		c.parseExtensions, c.optionsSet[template.Extension] = c.optionsSet[template.Extension], false
 	}
 
-	switch *strictAssignations {
+	switch *c.strictAssignations {
 	case "on":
 		template.StrictAssignationMode = template.AssignationValidationStrict
 	case "warning":
@@ -182,135 +541,375 @@ func runGotemplate() (exitCode int) {
 	}
 
 	// Set the recursion level
-	if *recursionDepth != 0 {
-		template.ExtensionDepth = *recursionDepth
+	if *c.recursionDepth != 0 {
+		template.ExtensionDepth = *c.recursionDepth
 	}
-	if *recursive && *recursionDepth == 0 {
+	if *c.recursive && *c.recursionDepth == 0 {
 		// If recursive is specified, but there is not recursion depth, we set it to a huge depth
-		*recursionDepth = 1 << 16
+		*c.recursionDepth = 1 << 16
 	}
 
-	if colorIsSet {
-		color.NoColor = !*colorEnabled
+	if c.colorIsSet {
+		color.NoColor = !*c.colorEnabled
 	}
 
-	if *getVersion {
+	if *c.getVersion {
 		fmt.Println(version)
-		return 0
+		return 0, true
+	}
+
+	if command == c.completion.FullCommand() {
+		return 0, false
+	}
+
+	if *c.printEffectiveConfig {
+		if err := printConfig(app, command, *c.configFile); err != nil {
+			errors.Print(err)
+			return 1, true
+		}
+		return 0, true
 	}
 
-	if *ignoreMissingPaths {
-		*ignoreMissingImport = true
-		*ignoreMissingSource = true
+	if *c.ignoreMissingPaths {
+		*c.ignoreMissingImport = true
+		*c.ignoreMissingSource = true
 	}
 
-	if err := template.TemplateLog.SetHookLevel("", *templateLogLevel); err != nil {
+	if err := template.TemplateLog.SetHookLevel("", *c.templateLogLevel); err != nil {
 		errors.Printf("Unable to set logging level for templates: %v", err)
 	}
-	if err := template.InternalLog.SetHookLevel("", *internalLogLevel); err != nil {
+	if err := template.InternalLog.SetHookLevel("", *c.internalLogLevel); err != nil {
 		errors.Printf("Unable to set logging level for internal logs: %v", err)
 	}
-	if path := *logFilePath; path != "" {
-		template.TemplateLog.AddFile(path, false, *templateLogFileLevel)
-		template.InternalLog.AddFile(path, false, *internalLogFileLevel)
+	if path := *c.logFilePath; path != "" {
+		template.TemplateLog.AddFile(path, false, *c.templateLogFileLevel)
+		template.InternalLog.AddFile(path, false, *c.internalLogFileLevel)
 	}
 
-	if *targetFolder == "" {
+	if *c.targetFolder == "" {
 		// Target folder default to source folder
-		*targetFolder = *sourceFolder
+		*c.targetFolder = *c.sourceFolder
 	}
-	*sourceFolder = errors.Must(filepath.Abs(*sourceFolder)).(string)
-	if _, err := os.Stat(*sourceFolder); os.IsNotExist(err) {
-		if !*ignoreMissingSource {
-			errors.Printf("Source folder: %s does not exist", *sourceFolder)
-			return 1
+	*c.sourceFolder = errors.Must(filepath.Abs(*c.sourceFolder)).(string)
+	if _, err := os.Stat(*c.sourceFolder); os.IsNotExist(err) {
+		if !*c.ignoreMissingSource {
+			errors.Printf("Source folder: %s does not exist", *c.sourceFolder)
+			return 1, true
 		}
-		template.InternalLog.Debugf("Source folder: %s does not exist, skipping gotemplate", *sourceFolder)
-		return 0
+		template.InternalLog.Debugf("Source folder: %s does not exist, skipping gotemplate", *c.sourceFolder)
+		return 0, true
 	}
 
-	*targetFolder = errors.Must(filepath.Abs(*targetFolder)).(string)
+	*c.targetFolder = errors.Must(filepath.Abs(*c.targetFolder)).(string)
+	if *c.errorOutput != "" {
+		// The report is written once we moved into the source folder
+		*c.errorOutput = errors.Must(filepath.Abs(*c.errorOutput)).(string)
+	}
+
+	if command == c.clean.FullCommand() || command == c.run.FullCommand() && *c.writeManifest && !*c.diffMode {
+		if c.manifest, err = template.LoadManifest(*c.targetFolder); err != nil {
+			errors.Printf("Unable to load the manifest: %v", err)
+			return 1, true
+		}
+	}
 
 	// If target folder is not equal to source folder, we run in overwrite mode by default
-	*overwrite = *overwrite || *sourceFolder != *targetFolder
+	*c.overwrite = *c.overwrite || *c.sourceFolder != *c.targetFolder
 
 	stat, _ := os.Stdin.Stat()
-	if (stat.Mode()&os.ModeCharDevice) == 0 && os.Getenv(envDisableStdinCheck) == "" {
-		*forceStdin = true
+	c.interactive = (stat.Mode() & os.ModeCharDevice) != 0
+	if !c.interactive && os.Getenv(envDisableStdinCheck) == "" && command != c.testCommand.FullCommand() && command != c.check.FullCommand() {
+		*c.forceStdin = true
 	}
 
-	if *removeEmptyLines {
+	if *c.removeEmptyLines {
 		// Options to remove empty lines
-		*substitutes = append(*substitutes, `/^\s*$/d`)
+		*c.substitutes = append(*c.substitutes, `/^\s*$/d`)
+	}
+
+	if *c.watchMode {
+		// The imported files must be reloaded after we moved into the source folder
+		*c.varFiles, *c.varFilesIfExist = absFiles(*c.varFiles), absFiles(*c.varFilesIfExist)
 	}
 
-	context, err := createContext(*varFiles, *varFilesIfExist, *namedVars, *typeMode, *ignoreMissingImport)
+	c.workingFolder = utils.Pwd()
+	if command == c.serve.FullCommand() {
+		// The extensions are loaded from the served folder
+		c.workingFolder = *c.sourceFolder
+	}
+	return 0, false
+}
+
+// Creates the template with the context resulting from the imported files and variables.
+func (c *commandLine) createTemplate() (*template.Template, int) {
+	context, err := createContext(*c.varFiles, *c.varFilesIfExist, *c.namedVars, *c.typeMode, *c.ignoreMissingImport)
 	if err != nil {
 		errors.Print(err)
-		return 1
+		return nil, 1
 	}
 
-	t, err := template.NewTemplate("", context, *delimiters, optionsSet, *substitutes...)
+	t, err := template.NewTemplateWithPlugins(c.workingFolder, context, *c.delimiters, c.optionsSet, *c.plugins, *c.substitutes...)
 	if err != nil {
 		errors.Print(err)
-		return 3
+		return nil, 3
+	}
+	if c.parseExtensions {
+		t.ParseExtensions()
+	}
+	t.TempFolder(tempFolder).Jobs(*c.jobs).RecordManifest(c.manifest).TemplateTimeout(*c.templateTimeout)
+	if *c.sandbox {
+		if len(*c.sandboxRoots) == 0 {
+			*c.sandboxRoots = []string{c.workingFolder, *c.sourceFolder}
+		}
+		t.SandboxRoots(*c.sandboxRoots...)
 	}
-	t.TempFolder(tempFolder)
 
-	if len(*ignoreRazor) > 0 {
-		t.AppendIgnoreRazorExpression(*ignoreRazor...)
+	if len(*c.ignoreRazor) > 0 {
+		t.AppendIgnoreRazorExpression(*c.ignoreRazor...)
 	}
+	return t, 0
+}
 
-	if command == list.FullCommand() {
-		if !(*listFunctions || *listTemplates) {
-			// If neither list functions or templates is selected, we default to list functions
-			*listFunctions = true
-		}
-		t = t.GetNewContext("", false)
-		if *listFunctions {
-			t.PrintFunctions(*listAll, *listLong, *listCategory, *listFilters...)
-		}
-		if *listTemplates {
-			t.PrintTemplates(*listAll, *listLong)
+// Moves into the source folder and returns the selected templates and the ones explicitly specified on the
+// command line.
+func (c *commandLine) selectTemplates(exitCode int) (templates, explicitFiles []string, _ int) {
+	errors.Must(os.Chdir(*c.sourceFolder))
+	if !*c.forceStdin && len(*c.templates) == 0 {
+		// We only process template files if go template has not been called with piped input or explicit files
+		*c.includePatterns = append(*c.includePatterns, "*.gt,*.template")
+	}
+
+	explicitFiles = make([]string, 0, len(*c.templates))
+	for _, template := range *c.templates {
+		if _, err := os.Stat(template); err == nil {
+			explicitFiles = append(explicitFiles, template)
 		}
-		return 0
+	}
+	templates, err := c.findTemplates(*c.templates...)
+	if err != nil {
+		errors.Print(err)
+		exitCode = 1
 	}
 
-	errors.Must(os.Chdir(*sourceFolder))
-	if !*forceStdin && len(*templates) == 0 {
-		// We only process template files if go template has not been called with piped input or explicit files
-		*includePatterns = append(*includePatterns, "*.gt,*.template")
+	if *c.forceStdin && stdinContent == "" {
+		templates = append(templates, readStdin())
 	}
+	return templates, explicitFiles, exitCode
+}
 
-	*templates = append(utils.MustFindFilesMaxDepth(*sourceFolder, *recursionDepth, *followSymLinks, extend(*includePatterns)...), *templates...)
-	for i, template := range *templates {
-		if file, err := filepath.Rel(*sourceFolder, template); err == nil {
+// Returns the templates found in the source folder followed by the supplied templates (without the excluded ones).
+func (c *commandLine) findTemplates(templates ...string) ([]string, error) {
+	templates = append(utils.MustFindFilesMaxDepth(*c.sourceFolder, *c.recursionDepth, *c.followSymLinks, extend(*c.includePatterns)...), templates...)
+	for i, template := range templates {
+		if file, err := filepath.Rel(*c.sourceFolder, template); err == nil {
 			if _, err = os.Stat(file); err == nil {
-				(*templates)[i] = file
+				templates[i] = file
 			}
 		}
 	}
-	*templates, err = exclude(*templates, *excludedPatterns)
+	return exclude(templates, *c.excludedPatterns)
+}
+
+func (c *commandLine) runCompletion() int {
+	if err := generateCompletion(os.Stdout, c.completionModel, c.enums, *c.completionShell); err != nil {
+		errors.Print(err)
+		return 1
+	}
+	return 0
+}
+
+func (c *commandLine) runClean() int {
+	if err := cleanGeneratedFiles(c.manifest, *c.cleanAll, *c.cleanRestore, *c.cleanForce, *c.cleanDryRun); err != nil {
+		errors.Print(err)
+		return 1
+	}
+	return 0
+}
+
+func (c *commandLine) runContext() int {
+	context, provenance, err := createContextWithProvenance(*c.varFiles, *c.varFilesIfExist, *c.namedVars, *c.typeMode, *c.ignoreMissingImport)
+	if err == nil {
+		err = printContext(os.Stdout, context, provenance, *c.contextFormat, *c.contextExplain)
+	}
+	if err != nil {
+		errors.Print(err)
+		return 1
+	}
+	return 0
+}
+
+func (c *commandLine) runList(t *template.Template) int {
+	if !(*c.listFunctions || *c.listTemplates) {
+		// If neither list functions or templates is selected, we default to list functions
+		*c.listFunctions = true
+	}
+	t = t.GetNewContext("", false)
+	if *c.listFormat != listFormatText {
+		var result listResult
+		if *c.listFunctions {
+			result.Functions = t.DescribeFunctions(*c.listAll, *c.listFilters...)
+		}
+		if *c.listTemplates {
+			result.Templates = t.DescribeTemplates(*c.listAll)
+		}
+		if err := printList(os.Stdout, result, *c.listFormat); err != nil {
+			errors.Print(err)
+			return 1
+		}
+		return 0
+	}
+	if *c.listFunctions {
+		t.PrintFunctions(*c.listAll, *c.listLong, *c.listCategory, *c.listFilters...)
+	}
+	if *c.listTemplates {
+		t.PrintTemplates(*c.listAll, *c.listLong)
+	}
+	return 0
+}
+
+func (c *commandLine) runDocs(t *template.Template) int {
+	if err := generateDocs(t.Documentation(), *c.docsFormat, *c.docsOutput); err != nil {
+		errors.Print(err)
+		return 1
+	}
+	return 0
+}
+
+func (c *commandLine) runRepl(t *template.Template) int {
+	if err := runRepl(t, os.Stdin, os.Stdout, c.interactive); err != nil {
+		errors.Print(err)
+		return 1
+	}
+	return 0
+}
+
+func (c *commandLine) runLsp(t *template.Template) int {
+	if err := runLsp(t, c.workingFolder, os.Stdin, os.Stdout); err != nil {
+		errors.Print(err)
+		return 1
+	}
+	return 0
+}
+
+func (c *commandLine) runServe(t *template.Template) int {
+	errors.Must(os.Chdir(*c.sourceFolder))
+	templates := utils.MustFindFilesMaxDepth(*c.sourceFolder, *c.recursionDepth, *c.followSymLinks, extend(append(*c.includePatterns, "*.gt,*.template"))...)
+	templates, err := exclude(templates, *c.excludedPatterns)
 	if err != nil {
 		errors.Print(err)
-		exitCode = 1
+		return 1
+	}
+	if err := serveTemplates(*c.serveAddress, t, *c.sourceFolder, templates); err != nil {
+		errors.Print(err)
+		return 1
 	}
+	return 0
+}
 
-	if *forceStdin && stdinContent == "" {
-		*templates = append(*templates, readStdin())
+func (c *commandLine) runCheck(t *template.Template, templates []string, exitCode int) int {
+	err := t.CheckTemplates(templates...)
+	printDiagnostics(*c.errorFormat, *c.errorOutput, c.workingFolder, err, t.Warnings())
+	if err != nil {
+		return 1
 	}
+	return exitCode
+}
 
-	resultFiles, err := t.ProcessTemplates(*sourceFolder, *targetFolder, *templates...)
+func (c *commandLine) runTest(t *template.Template, templates []string, exitCode int) int {
+	if runGoldenTests(t, os.Stdout, *c.testUpdate, templates...) > 0 {
+		return 1
+	}
+	return exitCode
+}
+
+func (c *commandLine) runGraph(t *template.Template, templates []string, exitCode int) int {
+	dependencies, err := t.Dependencies(templates...)
 	if err != nil {
 		errors.Print(err)
 		exitCode = 1
 	}
+	if err := printGraph(os.Stdout, dependencies, *c.graphFormat); err != nil {
+		errors.Print(err)
+		return 1
+	}
+	return exitCode
+}
+
+// Renders the templates (default command).
+func (c *commandLine) runTemplates(t *template.Template, templates, explicitFiles []string, exitCode int) int {
+	// The timeout applies to each rendering (the initial one and the ones triggered by the changes in watch mode)
+	process := func(t *template.Template, templates ...string) ([]string, error) {
+		ctx := context.Background()
+		if *c.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *c.timeout)
+			defer cancel()
+		}
+		return t.ProcessTemplatesContext(ctx, *c.sourceFolder, *c.targetFolder, templates...)
+	}
+	resultFiles, err := process(t, templates...)
+	printDiagnostics(*c.errorFormat, *c.errorOutput, c.workingFolder, err, t.Warnings())
+	if err != nil {
+		exitCode = 1
+	}
+
+	if *c.watchMode {
+		c.watch(t, resultFiles, explicitFiles, process)
+		return exitCode
+	}
+
+	if *c.diffMode {
+		if len(resultFiles) > 0 && exitCode == 0 {
+			exitCode = exitCodeDrift
+		}
+		return exitCode
+	}
 
 	// Apply terraform fmt if some generated files are terraform files
-	if !*printOutput {
+	if !*c.printOutput {
+		utils.TerraformFormat(resultFiles...)
+	}
+	if !saveManifest(c.manifest) {
+		exitCode = 1
+	}
+	return exitCode
+}
+
+// Renders the templates again when they change (until the process is interrupted).
+func (c *commandLine) watch(t *template.Template, resultFiles, explicitFiles []string, process func(*template.Template, ...string) ([]string, error)) {
+	stop, signals := make(chan struct{}), make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+	render := func(t *template.Template, templates ...string) {
+		resultFiles, err := process(t, templates...)
+		printDiagnostics(*c.errorFormat, *c.errorOutput, c.workingFolder, err, t.Warnings())
+		if !*c.printOutput && !*c.diffMode {
+			utils.TerraformFormat(resultFiles...)
+		}
+		saveManifest(c.manifest)
+	}
+	create := func() *template.Template {
+		t, _ := c.createTemplate()
+		return t
+	}
+	findTemplates := func() []string {
+		templates, err := c.findTemplates(explicitFiles...)
+		if err != nil {
+			errors.Print(err)
+		}
+		return templates
+	}
+	if !*c.printOutput && !*c.diffMode {
 		utils.TerraformFormat(resultFiles...)
 	}
-	return
+	saveManifest(c.manifest)
+	imports := append([]string(nil), *c.varFiles...)
+	imports = append(imports, *c.varFilesIfExist...)
+	imports = append(imports, namedVarFiles(*c.namedVars)...)
+	watchTemplates(stop, *c.watchInterval, t, imports, findTemplates, create, render)
 }
 
 type flag interface {
@@ -116,6 +116,14 @@ func TestCli(t *testing.T) {
 			expectedCode: 0,
 		},
 
+		// Diff mode
+		{
+			name:         "Diff mode with drift",
+			args:         []string{"--diff"},
+			template:     "{{ add 3 2 }}",
+			expectedCode: exitCodeDrift,
+		},
+
 		// Errors
 		{
 			name:         "Error recovery",
@@ -177,3 +185,51 @@ func TestCli(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		template     string
+		extension    string
+		expectedCode int
+	}{
+		{"Valid template", "@(3 + 2)", "", 0},
+		{"Not executed", `@exit(2)`, "", 0},
+		{"Undefined function", "@undefined_func()", "", 1},
+		{"Unclosed action", "{{ add 3 2", "", 1},
+		{"Extension not executed", `@greet()@include("hello")`, `@save("saved", "x")@alias("greet", "template", "hello")@define("hello")Hello@end`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldArgs := os.Args
+			defer func() { os.Args = oldArgs }()
+			oldCw := must(os.Getwd()).(string)
+			defer os.Chdir(oldCw)
+
+			// The check command must not read its template from stdin when it is not a terminal (i.e. in CI)
+			reader, writer, err := os.Pipe()
+			assert.NoError(t, err)
+			writer.Close()
+			oldStdin := os.Stdin
+			defer func() {
+				reader.Close()
+				os.Stdin = oldStdin
+			}()
+			os.Stdin = reader
+
+			tempDir := t.TempDir()
+			os.Chdir(tempDir)
+			assert.NoError(t, os.WriteFile(path.Join(tempDir, "test.gt"), []byte(tt.template), 0644))
+			if tt.extension != "" {
+				assert.NoError(t, os.WriteFile(path.Join(tempDir, "test.gte"), []byte(tt.extension), 0644))
+			}
+			os.Args = []string{"gotemplate", "check", "--source", tempDir}
+
+			assert.Equal(t, tt.expectedCode, runGotemplate(), "Bad exit code")
+			_, err = os.Stat(path.Join(tempDir, "test"))
+			assert.True(t, os.IsNotExist(err), "The template should not be rendered")
+			_, err = os.Stat(path.Join(tempDir, "saved"))
+			assert.True(t, os.IsNotExist(err), "The extension should not be executed")
+		})
+	}
+}
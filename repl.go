@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/coveooss/gotemplate/v3/template"
+)
+
+const replSource = "repl.gt"
+
+const replHelp = `Enter razor or go template expressions, they are evaluated against a persistent context.
+Global assignations (i.e. @x := 3) are kept between lines, but local variables (i.e. @{x} := 3) are not.
+A line ending with \ is continued on the next line.
+
+Commands:
+  :razor [code]      Show the go template generated from the code (default to the previous input)
+  :funcs [filter...] List the functions matching the filters (name, alias, category or description)
+  :load [name=]file  Import the variables defined in a data file (YAML, JSON or HCL)
+  :help              Show this help
+  :quit              Exit the REPL (also on end of input)
+`
+
+// Read the expressions from the input and print the evaluation results to the output until the end of input
+// (or :quit) is reached. The prompt is only printed if interactive is set (i.e. the input is a terminal).
+func runRepl(t *template.Template, in io.Reader, out io.Writer, interactive bool) error {
+	prompt := func(continued bool) {
+		if !interactive {
+			return
+		} else if continued {
+			fmt.Fprint(out, ". ")
+		} else {
+			fmt.Fprint(out, "> ")
+		}
+	}
+
+	var previous string
+	var pending []string
+	scanner := bufio.NewScanner(in)
+	for prompt(false); scanner.Scan(); prompt(len(pending) > 0) {
+		line := scanner.Text()
+		if strings.HasSuffix(line, `\`) {
+			pending = append(pending, strings.TrimSuffix(line, `\`))
+			continue
+		}
+		input := strings.Join(append(pending, line), "\n")
+		pending = nil
+
+		if strings.TrimSpace(input) == "" {
+			continue
+		}
+		if !strings.HasPrefix(input, ":") {
+			previous = input
+			result, err := t.EvaluateContent(input, replSource)
+			if err != nil {
+				fmt.Fprintln(out, err)
+				continue
+			}
+			if result != "" && !strings.HasSuffix(result, "\n") {
+				result += "\n"
+			}
+			fmt.Fprint(out, result)
+			continue
+		}
+
+		command, argument := collections.Split2(strings.TrimSpace(input[1:]), " ")
+		argument = strings.TrimSpace(argument)
+		switch command {
+		case "q", "quit", "exit":
+			return nil
+		case "h", "help":
+			fmt.Fprint(out, replHelp)
+		case "razor":
+			if argument == "" {
+				argument = previous
+			}
+			fmt.Fprintln(out, strings.TrimSuffix(t.ConvertRazor(argument), "\n"))
+		case "funcs":
+			functions := t.GetNewContext("", false).FindFunctions(strings.Fields(argument)...)
+			sort.Strings(functions)
+			fmt.Fprintln(out, strings.Join(functions, " "))
+		case "load":
+			if err := replLoad(t, argument); err != nil {
+				fmt.Fprintln(out, err)
+			}
+		default:
+			fmt.Fprintf(out, "Unknown command :%s (use :help to get the list of available commands)\n", command)
+		}
+	}
+	return scanner.Err()
+}
+
+// Import the data file into the template context. If the file is prefixed by name=, the content is
+// imported under that name, otherwise, its variables are added at the root of the context.
+func replLoad(t *template.Template, argument string) error {
+	if argument == "" {
+		return fmt.Errorf("usage: :load [name=]file")
+	}
+	var files, namedVars []string
+	if strings.Contains(argument, "=") {
+		namedVars = []string{argument}
+	} else {
+		files = []string{argument}
+	}
+	context, err := createContext(files, nil, namedVars, "", false)
+	if err != nil {
+		return err
+	}
+	for key, value := range context.AsMap() {
+		t.Context().Set(key, value)
+	}
+	return nil
+}
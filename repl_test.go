@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepl(t *testing.T) {
+	folder := t.TempDir()
+	data := filepath.Join(folder, "data.yaml")
+	assert.NoError(t, os.WriteFile(data, []byte("name: world\n"), 0644))
+
+	input := strings.Join([]string{
+		"@x := 3",
+		"@(x * 2)",
+		"{{ add $.x 1 }}",
+		":razor @(x + 1)",
+		":razor",
+		":load " + data,
+		"Hello @name",
+		":load imported=" + data,
+		":load",
+		"@imported.name",
+		"{{ add 1 \\",
+		"2 }}",
+		":funcs truncSprig",
+		":unknown",
+		":quit",
+		"@x",
+	}, "\n")
+
+	var out bytes.Buffer
+	tpl := template.MustNewTemplate(folder, nil, "", nil)
+	assert.NoError(t, runRepl(tpl, strings.NewReader(input), &out, false))
+	assert.Equal(t, strings.Join([]string{
+		"6",
+		"4",
+		"{{ add $.x 1 }}",
+		"{{ add $.x 1 }}",
+		"Hello world",
+		"usage: :load [name=]file",
+		"world",
+		"3",
+		"truncSprig",
+		"Unknown command :unknown (use :help to get the list of available commands)",
+		"",
+	}, "\n"), out.String())
+}
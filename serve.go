@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/coveooss/gotemplate/v3/utils"
+)
+
+// The content of a render request.
+type renderRequest struct {
+	Template string      `json:"template"` // The template code (razor or go template)
+	File     string      `json:"file"`     // The name of a template preloaded from the source folder (instead of template)
+	Context  interface{} `json:"context"`  // The context, either as an object or as a JSON, YAML or HCL string
+}
+
+// The content of a render response, the result is only set if there is no error.
+type renderResponse struct {
+	Result string                    `json:"result"`
+	Errors []*template.TemplateError `json:"errors,omitempty"`
+}
+
+const renderSource = "request.gt"
+
+// Handle the render requests (POST /render) and the list of preloaded templates (GET /templates). The templates found
+// in the source folder are read once when the server is created and the extensions (.gte) have already been loaded by
+// the supplied template. Each request is rendered on a distinct copy of the template context, functions and aliases.
+//
+// Since the templates may have side effects, the render requests must be explicitly sent as JSON and must not come
+// from another origin. This prevents a web page from submitting a template through a simple cross-origin request.
+type renderServer struct {
+	*http.ServeMux
+	template  *template.Template
+	folder    string
+	templates map[string]string
+}
+
+func newRenderServer(t *template.Template, folder string, templates []string) (*renderServer, error) {
+	server := &renderServer{
+		ServeMux:  http.NewServeMux(),
+		template:  t,
+		folder:    folder,
+		templates: make(map[string]string, len(templates)),
+	}
+	for _, file := range templates {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		server.templates[filepath.ToSlash(utils.Relative(folder, file))] = string(content)
+	}
+	server.HandleFunc("/render", server.render)
+	server.HandleFunc("/templates", server.list)
+	return server, nil
+}
+
+func (s *renderServer) list(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed, use GET", r.Method))
+		return
+	}
+	names := make([]string, 0, len(s.templates))
+	for name := range s.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, names)
+}
+
+func (s *renderServer) render(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed, use POST", r.Method))
+		return
+	}
+
+	if err := checkRenderRequest(r); err != nil {
+		writeJSONError(w, http.StatusForbidden, err)
+		return
+	}
+
+	var request renderRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+		return
+	}
+
+	code, source := request.Template, renderSource
+	if request.File != "" {
+		var found bool
+		if code, found = s.templates[filepath.ToSlash(request.File)]; !found {
+			writeJSONError(w, http.StatusNotFound, fmt.Errorf("template %s not found", request.File))
+			return
+		}
+		source = filepath.Join(s.folder, request.File)
+	}
+
+	var context collections.IDictionary
+	if text, isString := request.Context.(string); isString {
+		var data map[string]interface{}
+		if err := collections.ConvertData(text, &data); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid context: %v", err))
+			return
+		}
+		request.Context = data
+	}
+	if request.Context != nil {
+		var err error
+		if context, err = collections.TryAsDictionary(request.Context); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid context: %v", err))
+			return
+		}
+	}
+
+	result, err := s.template.CloneWithContext(context).ProcessContent(code, source)
+	if err != nil {
+		diagnostics := template.Diagnostics(err)
+		for _, diagnostic := range diagnostics {
+			if diagnostic.File != "" && diagnostic.File != renderSource {
+				diagnostic.File = filepath.ToSlash(utils.Relative(s.folder, diagnostic.File))
+			}
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, renderResponse{Errors: diagnostics})
+		return
+	}
+	writeJSON(w, http.StatusOK, renderResponse{Result: result})
+}
+
+// Ensure that the request has been sent by a client able to set the content type (which is not allowed by browsers
+// on simple cross-origin requests) and that it does not come from a foreign web page.
+func checkRenderRequest(r *http.Request) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return fmt.Errorf("invalid content type %q, use application/json", r.Header.Get("Content-Type"))
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			return fmt.Errorf("requests from origin %s are not allowed", origin)
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		template.InternalLog.Error(err)
+	}
+}
+
+// Errors that are not related to the template itself (i.e. invalid request) are returned in the same format
+// as the template errors, but without location.
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, renderResponse{Errors: template.Diagnostics(err)})
+}
+
+// Listen on the address until the process is interrupted.
+func serveTemplates(address string, t *template.Template, folder string, templates []string) error {
+	handler, err := newRenderServer(t, folder, templates)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Addr: address, Handler: handler}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		server.Close()
+	}()
+
+	template.InternalLog.Infof("Serving %d template(s) from %s on http://%s", len(templates), folder, address)
+	if err = server.ListenAndServe(); err == http.ErrServerClosed {
+		err = nil
+	}
+	return err
+}
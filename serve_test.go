@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderServer(t *testing.T) {
+	folder := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "ext.gte"), []byte(`@define("hello")Hello @name@end`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "file.gt"), []byte(`@include("hello", $) from file`), 0644))
+
+	tpl := template.MustNewTemplate(folder, map[string]interface{}{"name": "default"}, "", nil)
+	handler, err := newRenderServer(tpl, folder, []string{filepath.Join(folder, "file.gt")})
+	assert.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		headers  map[string]string
+		body     string
+		status   int
+		expected string
+	}{
+		{"Razor", "POST", "/render", nil, `{"template": "@(a + b)", "context": {"a": 1, "b": 2}}`, http.StatusOK, `{"result": "3"}`},
+		{"Go template with YAML context", "POST", "/render", nil, `{"template": "{{ .list | join \",\" }}", "context": "list: [a, b]"}`, http.StatusOK, `{"result": "a,b"}`},
+		{"Context is not shared", "POST", "/render", nil, `{"template": "@name := \"changed\"@name"}`, http.StatusOK, `{"result": "changed"}`},
+		{"Define alias", "POST", "/render", nil, `{"template": "@alias(\"greet\", \"template\", \"hello\")@func(\"twice\", \"template\", \"hello\", dict())"}`, http.StatusOK, `{"result": ""}`},
+		{
+			"Alias is not shared", "POST", "/render", nil, `{"template": "@greet($)"}`, http.StatusUnprocessableEntity,
+			`{"result": "", "errors": [{"file": "request.gt", "line": 1, "excerpt": "@greet($)", "message": "function \"greet\" not defined", "severity": "error"}]}`,
+		},
+		{
+			"Func is not shared", "POST", "/render", nil, `{"template": "@twice()"}`, http.StatusUnprocessableEntity,
+			`{"result": "", "errors": [{"file": "request.gt", "line": 1, "excerpt": "@twice()", "message": "function \"twice\" not defined", "severity": "error"}]}`,
+		},
+		{"Extension", "POST", "/render", nil, `{"template": "@include(\"hello\", $)"}`, http.StatusOK, `{"result": "Hello default"}`},
+		{"Preloaded file", "POST", "/render", nil, `{"file": "file.gt", "context": {"name": "world"}}`, http.StatusOK, `{"result": "Hello world from file"}`},
+		{"List", "GET", "/templates", nil, "", http.StatusOK, `["file.gt"]`},
+		{
+			"Template error", "POST", "/render", nil, `{"template": "Line 1\n@unknown_func()"}`, http.StatusUnprocessableEntity,
+			`{"result": "", "errors": [{"file": "request.gt", "line": 2, "excerpt": "@unknown_func()", "message": "function \"unknown_func\" not defined", "severity": "error"}]}`,
+		},
+		{
+			"Unknown file", "POST", "/render", nil, `{"file": "missing.gt"}`, http.StatusNotFound,
+			`{"result": "", "errors": [{"file": "", "message": "template missing.gt not found", "severity": "error"}]}`,
+		},
+		{
+			"Invalid request", "POST", "/render", nil, `{`, http.StatusBadRequest,
+			`{"result": "", "errors": [{"file": "", "message": "invalid request: unexpected EOF", "severity": "error"}]}`,
+		},
+		{
+			"Invalid content type", "POST", "/render", map[string]string{"Content-Type": "text/plain"}, `{"template": "@exec(\"echo\")"}`, http.StatusForbidden,
+			`{"result": "", "errors": [{"file": "", "message": "invalid content type \"text/plain\", use application/json", "severity": "error"}]}`,
+		},
+		{
+			"Foreign origin", "POST", "/render", map[string]string{"Origin": "http://example.com"}, `{"template": "@exec(\"echo\")"}`, http.StatusForbidden,
+			`{"result": "", "errors": [{"file": "", "message": "requests from origin http://example.com are not allowed", "severity": "error"}]}`,
+		},
+		{
+			"Invalid method", "GET", "/render", nil, "", http.StatusMethodNotAllowed,
+			`{"result": "", "errors": [{"file": "", "message": "method GET not allowed, use POST", "severity": "error"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			if tt.method == http.MethodPost {
+				request.Header.Set("Content-Type", "application/json; charset=utf-8")
+			}
+			for key, value := range tt.headers {
+				request.Header.Set(key, value)
+			}
+			response, err := http.DefaultClient.Do(request)
+			assert.NoError(t, err)
+			defer response.Body.Close()
+			body := new(strings.Builder)
+			_, err = io.Copy(body, response.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, response.StatusCode)
+			assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+			assert.JSONEq(t, tt.expected, body.String())
+		})
+	}
+}
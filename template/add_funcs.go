@@ -47,6 +47,9 @@ func (t *Template) addFuncs() {
 	add(Net, t.addNetFuncs)
 	add(OS, t.addOSFuncs)
 	add(Git, t.addGitFuncs)
+	t.addPluginFuncs(t.plugins...)
+	add(Deterministic, t.addDeterministicFuncs)
+	add(Sandbox, t.addSandboxFuncs)
 }
 
 // Apply all regular expressions replacements to the supplied string
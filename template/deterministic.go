@@ -0,0 +1,246 @@
+package template
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coveooss/gotemplate/v3/utils"
+)
+
+// The instant returned by now in deterministic mode when it is not specified (through GOTEMPLATE_NOW or Deterministic).
+var defaultDeterministicNow = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Holds the fixed clock and the random source shared by the template and all its contexts in deterministic mode.
+type determinism struct {
+	now    time.Time
+	seed   int64
+	random *rand.Rand
+}
+
+// The random source is shared between all the functions, so its access must be synchronized.
+type lockedSource struct {
+	sync.Mutex
+	source rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.Lock()
+	defer s.Unlock()
+	return s.source.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.Lock()
+	defer s.Unlock()
+	return s.source.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.Lock()
+	defer s.Unlock()
+	s.source.Seed(seed)
+}
+
+func (d *determinism) reset(seed int64, now time.Time) {
+	d.now, d.seed = now, seed
+	d.random = rand.New(&lockedSource{source: rand.NewSource(seed).(rand.Source64)})
+}
+
+// Seed the random source from the seed and the file name, so the values drawn while processing a file do not depend
+// on the files processed before it.
+func (t *Template) reseed(file string) {
+	if !t.options[Deterministic] {
+		return
+	}
+	if filepath.IsAbs(file) {
+		file = utils.Relative(t.folder, file)
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(filepath.ToSlash(file)))
+	t.determinism.random.Seed(t.determinism.seed ^ int64(hash.Sum64()))
+}
+
+// Returns the determinism settings defined by GOTEMPLATE_SEED and GOTEMPLATE_NOW (defined is false if none of them are set).
+func deterministicFromEnv() (seed int64, now time.Time, defined bool, err error) {
+	now = defaultDeterministicNow
+	if value := os.Getenv(EnvSeed); value != "" {
+		if seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return 0, now, true, fmt.Errorf("invalid value for %s: %v", EnvSeed, value)
+		}
+		defined = true
+	}
+	if value := os.Getenv(EnvNow); value != "" {
+		if now, err = ParseInstant(value); err != nil {
+			return 0, now, true, fmt.Errorf("invalid value for %s: %v", EnvNow, err)
+		}
+		defined = true
+	}
+	return
+}
+
+// ParseInstant converts a RFC 3339 date (with or without time) or a number of seconds since the unix epoch to time.
+func ParseInstant(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if instant, err := time.Parse(layout, value); err == nil {
+			return instant, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%s is not a RFC 3339 date nor a unix timestamp", value)
+}
+
+// Deterministic set the instant returned by the time functions and the seed of the random functions and enables the
+// deterministic mode. The random source is seeded again for each processed file (from the seed and the file name), so
+// a file always produces the same result whatever the other processed files are. The functions generating keys,
+// certificates or salted hashes cannot use the seeded source, so they return an error in deterministic mode.
+func (t *Template) Deterministic(seed int64, now time.Time) *Template {
+	t.determinism.reset(seed, now)
+	if !t.options[Deterministic] {
+		t.options[Deterministic] = true
+		t.addDeterministicFuncs()
+	}
+	return t
+}
+
+// Convert the date argument of the sprig date functions, the current time is used if it is not a date or a timestamp.
+func (d *determinism) date(date interface{}) time.Time {
+	switch date := date.(type) {
+	case time.Time:
+		return date
+	case *time.Time:
+		return *date
+	case int64:
+		return time.Unix(date, 0).In(d.now.Location())
+	case int:
+		return time.Unix(int64(date), 0).In(d.now.Location())
+	case int32:
+		return time.Unix(int64(date), 0).In(d.now.Location())
+	}
+	return d.now
+}
+
+func (d *determinism) dateInZone(format string, date interface{}, zone string) string {
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		location = time.UTC
+	}
+	return d.date(date).In(location).Format(format)
+}
+
+func (d *determinism) randomString(count int, characters string) string {
+	result := make([]byte, count)
+	for i := range result {
+		result[i] = characters[d.random.Intn(len(characters))]
+	}
+	return string(result)
+}
+
+const (
+	randomLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	randomDigits  = "0123456789"
+)
+
+// Printable ASCII characters (as generated by the sprig randAscii function).
+var randomASCII = func() string {
+	var ascii strings.Builder
+	for c := ' '; c <= '~'; c++ {
+		ascii.WriteRune(c)
+	}
+	return ascii.String()
+}()
+
+// rand.Read is not safe for concurrent use, so we build the bytes from the synchronized source.
+func (d *determinism) bytes(count int) []byte {
+	result := make([]byte, count)
+	for i := range result {
+		result[i] = byte(d.random.Intn(256))
+	}
+	return result
+}
+
+func (d *determinism) uuid() string {
+	uuid := d.bytes(16)
+	uuid[6] = (uuid[6] & 0x0f) | 0x40 // Version 4
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // Variant RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
+
+// The sprig functions relying on crypto/rand.
+var randomCryptoFunctions = []string{
+	"bcrypt", "htpasswd", "encryptAES",
+	"genPrivateKey", "genCA", "genCAWithKey", "genSelfSignedCert", "genSelfSignedCertWithKey", "genSignedCert", "genSignedCertWithKey",
+}
+
+// Replace the sprig and utils functions that depend on the current time or on a random source by functions using the
+// fixed instant and the seeded random source.
+func (t *Template) addDeterministicFuncs() {
+	d := t.determinism
+	replace := func(name string, function interface{}) {
+		original := t.functions[name]
+		if original == nil || original.alias != nil {
+			// The function is not available (library disabled)
+			return
+		}
+		replacement := *original
+		// We keep the original signature for the documentation
+		replacement.in, replacement.out = striptColor(original.Arguments()), original.Result()
+		replacement.function = function
+		t.addFunctions(funcTableMap{name: &replacement})
+	}
+
+	replace("now", func() time.Time { return d.now })
+	replace("date", func(format string, date interface{}) string { return d.date(date).Format(format) })
+	replace("dateInZone", d.dateInZone)
+	replace("htmlDate", func(date interface{}) string { return d.date(date).Format("2006-01-02") })
+	replace("htmlDateInZone", func(date interface{}, zone string) string { return d.dateInZone("2006-01-02", date, zone) })
+	replace("ago", func(date interface{}) string { return d.now.Sub(d.date(date)).Round(time.Second).String() })
+	if original, ok := t.functions["durationRound"]; ok && original.alias == nil {
+		original := original.function.(func(interface{}) string)
+		replace("durationRound", func(duration interface{}) string {
+			if date, isDate := duration.(time.Time); isDate {
+				duration = int64(d.now.Sub(date))
+			}
+			return original(duration)
+		})
+	}
+
+	replace("randAlpha", func(count int) string { return d.randomString(count, randomLetters) })
+	replace("randAlphaNum", func(count int) string { return d.randomString(count, randomLetters+randomDigits) })
+	replace("randNumeric", func(count int) string { return d.randomString(count, randomDigits) })
+	replace("randAscii", func(count int) string { return d.randomString(count, randomASCII) })
+	replace("randInt", func(min, max int) int { return d.random.Intn(max-min) + min })
+	replace("randBytes", func(count int) (string, error) {
+		return base64.StdEncoding.EncodeToString(d.bytes(count)), nil
+	})
+	replace("shuffle", func(str string) string {
+		runes := []rune(str)
+		d.random.Shuffle(len(runes), func(i, j int) { runes[i], runes[j] = runes[j], runes[i] })
+		return string(runes)
+	})
+	replace("uuidv4", d.uuid)
+	// The crypto functions draw their random values from crypto/rand, so they cannot produce reproducible results
+	for _, name := range randomCryptoFunctions {
+		name := name
+		replace(name, func(...interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("%s is not available in deterministic mode since its result is random", name)
+		})
+	}
+	replace("lorem", func(funcName interface{}, params ...int) (result string, err error) {
+		kind, err := utils.GetLoremKind(fmt.Sprint(funcName))
+		if err == nil {
+			result, err = utils.LoremFromSource(d.random, kind, params...)
+		}
+		return
+	})
+}
@@ -0,0 +1,88 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeterministic(t *testing.T) {
+	now := time.Date(2024, time.May, 6, 7, 8, 9, 0, time.UTC)
+	render := func(seed int64, content string) string {
+		template := MustNewTemplate(t.TempDir(), nil, "", nil).Deterministic(seed, now)
+		result, err := template.ProcessContent(content, "test.gt")
+		assert.NoError(t, err)
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"Now", `@now()`, "2024-05-06 07:08:09 +0000 UTC"},
+		{"Date", `@date("2006-01-02T15:04", now())`, "2024-05-06T07:08"},
+		{"Date without date", `@date("2006-01-02", "")`, "2024-05-06"},
+		{"Date in zone", `@dateInZone("15:04", now(), "UTC")`, "07:08"},
+		{"Html date", `@htmlDate(now())`, "2024-05-06"},
+		{"Ago", `@ago(toDate("2006-01-02", "2024-05-05"))`, "31h8m9s"},
+		{"Duration round", `@durationRound(toDate("2006-01-02", "2024-05-01"))`, "5d"},
+		{"Random int", `@randInt(10, 11)`, "10"},
+		{"Random numeric", `@len(randNumeric(12))`, "12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, render(1, tt.content))
+		})
+	}
+
+	content := `@randAlpha(10) @randAlphaNum(10) @randAscii(10) @randBytes(10) @shuffle("abcdefghij") @uuidv4() @guid() @lorem("paragraph")`
+	first := render(42, content)
+	assert.Equal(t, first, render(42, content), "Same seed produces the same result")
+	assert.NotEqual(t, first, render(43, content), "Different seeds produce different results")
+	assert.Regexp(t, `[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}`, first)
+}
+
+func TestDeterministicFiles(t *testing.T) {
+	folder, target := t.TempDir(), t.TempDir()
+	first, second := filepath.Join(folder, "first.txt.gt"), filepath.Join(folder, "second.txt.gt")
+	assert.NoError(t, os.WriteFile(first, []byte(`@randAlpha(16)`), 0644))
+	assert.NoError(t, os.WriteFile(second, []byte(`@randAlpha(16)`), 0644))
+	render := func(templates ...string) string {
+		template := MustNewTemplate(folder, nil, "", DefaultOptions().Set(Overwrite)).Deterministic(1, time.Now())
+		_, err := template.ProcessTemplates(folder, target, templates...)
+		assert.NoError(t, err)
+		content, err := os.ReadFile(filepath.Join(target, "second.txt"))
+		assert.NoError(t, err)
+		return string(content)
+	}
+
+	// The result of a file does not depend on the files processed before it
+	assert.Equal(t, render(second), render(first, second))
+	content, _ := os.ReadFile(filepath.Join(target, "first.txt"))
+	assert.NotEqual(t, render(second), string(content), "Each file has its own random values")
+
+	for _, function := range []string{`genPrivateKey("rsa")`, `bcrypt("password")`, `encryptAES("secret", "text")`} {
+		_, err := MustNewTemplate(folder, nil, "", nil).Deterministic(1, time.Now()).ProcessContent("@"+function, "test.gt")
+		assert.ErrorContains(t, err, "is not available in deterministic mode since its result is random")
+	}
+}
+
+func TestDeterministicFromEnv(t *testing.T) {
+	t.Setenv(EnvSeed, "7")
+	t.Setenv(EnvNow, "1700000000")
+	template := MustNewTemplate(t.TempDir(), nil, "", nil)
+	assert.True(t, template.options[Deterministic])
+	result, err := template.ProcessContent(`{{ now.Unix }} {{ randAlpha 16 }}`, "test.gt")
+	assert.NoError(t, err)
+	again, _ := MustNewTemplate(t.TempDir(), nil, "", nil).ProcessContent(`{{ now.Unix }} {{ randAlpha 16 }}`, "test.gt")
+	assert.Equal(t, result, again)
+	assert.Regexp(t, `^1700000000 [A-Za-z]{16}$`, result)
+
+	t.Setenv(EnvNow, "yesterday")
+	_, err = NewTemplate(t.TempDir(), nil, "", nil)
+	assert.EqualError(t, err, "invalid value for GOTEMPLATE_NOW: yesterday is not a RFC 3339 date nor a unix timestamp")
+}
@@ -262,10 +262,11 @@ var dataFuncsExamples = examples{
 
 func (t *Template) addDataFuncs() {
 	options := FuncOptions{
-		FuncHelp:     dataFuncsHelp,
-		FuncArgs:     dataFuncsArgs,
-		FuncAliases:  dataFuncsAliases,
-		FuncExamples: dataFuncsExamples,
+		FuncHelp:      dataFuncsHelp,
+		FuncArgs:      dataFuncsArgs,
+		FuncAliases:   dataFuncsAliases,
+		FuncExamples:  dataFuncsExamples,
+		FuncNamespace: "data",
 	}
 	t.AddFunctions(dataFuncsBase, dataBase, options)
 	t.AddFunctions(dataFuncsConversion, dataConversion, options)
@@ -32,9 +32,10 @@ var gitFuncsHelp = descriptions{
 
 func (t *Template) addGitFuncs() {
 	t.AddFunctions(gitFuncs, gitBase, FuncOptions{
-		FuncHelp:    gitFuncsHelp,
-		FuncArgs:    gitFuncsArgs,
-		FuncAliases: gitFuncsAliases,
+		FuncHelp:      gitFuncsHelp,
+		FuncArgs:      gitFuncsArgs,
+		FuncAliases:   gitFuncsAliases,
+		FuncNamespace: "git",
 	})
 }
 
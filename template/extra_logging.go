@@ -76,8 +76,9 @@ var loggingFuncsHelp = descriptions{
 
 func (t *Template) addLoggingFuncs() {
 	t.AddFunctions(loggingFuncs, loggingBase, FuncOptions{
-		FuncHelp:    loggingFuncsHelp,
-		FuncAliases: loggingFuncsAliases,
+		FuncHelp:      loggingFuncsHelp,
+		FuncAliases:   loggingFuncsAliases,
+		FuncNamespace: "logging",
 	})
 }
 
@@ -324,10 +324,11 @@ var mathFuncsExamples = examples{
 func (t *Template) addMathFuncs() {
 	// Enhance mathematic functions
 	options := FuncOptions{
-		FuncHelp:     mathFuncsHelp,
-		FuncArgs:     mathFuncsArgs,
-		FuncAliases:  mathFuncsAliases,
-		FuncExamples: mathFuncsExamples,
+		FuncHelp:      mathFuncsHelp,
+		FuncArgs:      mathFuncsArgs,
+		FuncAliases:   mathFuncsAliases,
+		FuncExamples:  mathFuncsExamples,
+		FuncNamespace: "math",
 	}
 
 	t.AddFunctions(mathBaseFuncs, mathBase, options)
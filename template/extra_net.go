@@ -11,11 +11,6 @@ const (
 	netBase = "Net"
 )
 
-var netFuncs = dictionary{
-	"httpGet": httpGet,
-	"httpDoc": httpDocument,
-}
-
 var netFuncsArgs = arguments{
 	"httpGet": {"url"},
 	"httpDoc": {"url"},
@@ -31,19 +26,32 @@ var netFuncsHelp = descriptions{
 }
 
 func (t *Template) addNetFuncs() {
+	netFuncs := dictionary{
+		"httpGet": t.httpGet,
+		"httpDoc": t.httpDocument,
+	}
 	t.AddFunctions(netFuncs, netBase, FuncOptions{
-		FuncHelp:    netFuncsHelp,
-		FuncArgs:    netFuncsArgs,
-		FuncAliases: netFuncsAliases,
+		FuncHelp:      netFuncsHelp,
+		FuncArgs:      netFuncsArgs,
+		FuncAliases:   netFuncsAliases,
+		FuncNamespace: "net",
 	})
 }
 
-func httpGet(url interface{}) (*http.Response, error) {
-	return http.Get(fmt.Sprint(url))
+func (t *Template) httpGet(url interface{}) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(t.getRunContext(), http.MethodGet, fmt.Sprint(url), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if interrupted := t.interrupted(fmt.Sprintf("request to %s", url)); err != nil && interrupted != nil {
+		err = interrupted
+	}
+	return response, err
 }
 
-func httpDocument(url interface{}) (interface{}, error) {
-	response, err := httpGet(url)
+func (t *Template) httpDocument(url interface{}) (interface{}, error) {
+	response, err := t.httpGet(url)
 	if err != nil {
 		return response, err
 	}
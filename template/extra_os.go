@@ -2,10 +2,12 @@ package template
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"os/user"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/coveooss/gotemplate/v3/collections"
@@ -18,27 +20,14 @@ const (
 )
 
 var osFuncs = dictionary{
-	"diff":         diff,
-	"exists":       fileExists,
-	"glob":         glob,
-	"group":        userGroup,
-	"home":         userHome,
-	"isDir":        isDir,
-	"isExecutable": isExecutable,
-	"isFile":       isFile,
-	"isReadable":   isReadable,
-	"isWriteable":  isWriteable,
-	"joinPath":     path.Join,
-	"lastMod":      lastMod,
-	"load":         loadFromFile,
-	"lookPath":     lookPath,
-	"mode":         fileMode,
-	"pwd":          utils.Pwd,
-	"save":         saveToFile,
-	"size":         fileSize,
-	"stat":         os.Stat,
-	"user":         user.Current,
-	"username":     username,
+	"diff":     diff,
+	"group":    userGroup,
+	"home":     userHome,
+	"joinPath": path.Join,
+	"lookPath": lookPath,
+	"pwd":      utils.Pwd,
+	"user":     user.Current,
+	"username": username,
 }
 
 var osFuncsArgs = arguments{
@@ -99,15 +88,35 @@ var osFuncsHelp = descriptions{
 }
 
 func (t *Template) addOSFuncs() {
-	t.AddFunctions(osFuncs, osBase, FuncOptions{
-		FuncHelp:    osFuncsHelp,
-		FuncArgs:    osFuncsArgs,
-		FuncAliases: osFuncsAliases,
+	funcs := dictionary{
+		"exists":       t.fileExists,
+		"glob":         t.glob,
+		"isDir":        t.isDir,
+		"isExecutable": t.isExecutable,
+		"isFile":       t.isFile,
+		"isReadable":   t.isReadable,
+		"isWriteable":  t.isWriteable,
+		"lastMod":      t.lastMod,
+		"load":         t.loadFromFile,
+		"mode":         t.fileMode,
+		"save":         t.saveToFile,
+		"size":         t.fileSize,
+		"stat":         t.statFile,
+	}
+	for key, value := range osFuncs {
+		funcs[key] = value
+	}
+
+	t.AddFunctions(funcs, osBase, FuncOptions{
+		FuncHelp:      osFuncsHelp,
+		FuncArgs:      osFuncsArgs,
+		FuncAliases:   osFuncsAliases,
+		FuncNamespace: "os",
 	})
 }
 
-func fileExists(file interface{}) (bool, error) {
-	if _, err := os.Stat(fmt.Sprint(file)); err != nil {
+func (t *Template) fileExists(file interface{}) (bool, error) {
+	if _, err := t.statFile(fmt.Sprint(file)); err != nil {
 		if os.IsNotExist(err) {
 			err = nil
 		}
@@ -116,72 +125,85 @@ func fileExists(file interface{}) (bool, error) {
 	return true, nil
 }
 
-func fileMode(file interface{}) (os.FileMode, error) {
-	stat, err := os.Stat(fmt.Sprint(file))
+func (t *Template) fileMode(file interface{}) (os.FileMode, error) {
+	stat, err := t.statFile(fmt.Sprint(file))
 	if err != nil {
 		return 0, err
 	}
 	return stat.Mode(), nil
 }
 
-func fileSize(file interface{}) (int64, error) {
-	stat, err := os.Stat(fmt.Sprint(file))
+func (t *Template) fileSize(file interface{}) (int64, error) {
+	stat, err := t.statFile(fmt.Sprint(file))
 	if err != nil {
 		return 0, err
 	}
 	return stat.Size(), nil
 }
 
-func isDir(file interface{}) (bool, error) {
-	stat, err := os.Stat(fmt.Sprint(file))
+func (t *Template) isDir(file interface{}) (bool, error) {
+	stat, err := t.statFile(fmt.Sprint(file))
 	if err != nil {
 		return false, err
 	}
 	return stat.IsDir(), nil
 }
 
-func isFile(file interface{}) (bool, error) {
-	isDir, err := isDir(file)
+func (t *Template) isFile(file interface{}) (bool, error) {
+	isDir, err := t.isDir(file)
 	if err != nil {
 		return false, err
 	}
 	return !isDir, nil
 }
 
-func isReadable(file interface{}) (bool, error) {
-	stat, err := os.Stat(fmt.Sprint(file))
+func (t *Template) isReadable(file interface{}) (bool, error) {
+	stat, err := t.statFile(fmt.Sprint(file))
 	if err != nil {
 		return false, err
 	}
 	return stat.Mode()&0400 != 0, nil
 }
 
-func isWriteable(file interface{}) (bool, error) {
-	stat, err := os.Stat(fmt.Sprint(file))
+func (t *Template) isWriteable(file interface{}) (bool, error) {
+	stat, err := t.statFile(fmt.Sprint(file))
 	if err != nil {
 		return false, err
 	}
 	return stat.Mode()&0200 != 0, nil
 }
 
-func isExecutable(file interface{}) (bool, error) {
-	stat, err := os.Stat(fmt.Sprint(file))
+func (t *Template) isExecutable(file interface{}) (bool, error) {
+	stat, err := t.statFile(fmt.Sprint(file))
 	if err != nil {
 		return false, err
 	}
 	return stat.Mode()&0100 != 0 && !stat.IsDir(), nil
 }
 
-func lastMod(file interface{}) (time.Time, error) {
-	stat, err := os.Stat(fmt.Sprint(file))
+func (t *Template) lastMod(file interface{}) (time.Time, error) {
+	stat, err := t.statFile(fmt.Sprint(file))
 	if err != nil {
 		return time.Time{}, err
 	}
 	return stat.ModTime(), nil
 }
 
-func glob(args ...interface{}) collections.IGenericList {
-	return collections.AsList(utils.GlobFuncTrim(args...))
+func (t *Template) glob(args ...interface{}) collections.IGenericList {
+	if t.fsys == nil {
+		return collections.AsList(utils.GlobFuncTrim(args...))
+	}
+	// The patterns are expanded in the template file system (the names are returned relative to its root)
+	var result []string
+	for _, arg := range collections.ToStrings(args) {
+		if strings.ContainsAny(arg, "*?[]") {
+			expanded, _ := fs.Glob(t.fsys, t.fsName(arg))
+			result = append(result, expanded...)
+			continue
+		}
+		result = append(result, arg)
+	}
+	return collections.AsList(result)
 }
 
 func diff(text1, text2 interface{}) interface{} {
@@ -190,7 +212,7 @@ func diff(text1, text2 interface{}) interface{} {
 	return dmp.DiffPrettyText(diffs)
 }
 
-func loadFromFile(filename string, binary ...bool) (interface{}, error) {
+func (t *Template) loadFromFile(filename string, binary ...bool) (interface{}, error) {
 	isBinary := false
 	switch len(binary) {
 	case 0:
@@ -200,16 +222,19 @@ func loadFromFile(filename string, binary ...bool) (interface{}, error) {
 	default:
 		return "", fmt.Errorf("invalid number of arguments")
 	}
-	content, err := os.ReadFile(filename)
+	content, err := t.readFile(filename)
 	if isBinary {
 		return content, err
 	}
 	return string(content), err
 }
 
-func saveToFile(filename string, object interface{}) (string, error) {
+func (t *Template) saveToFile(filename string, object interface{}) (string, error) {
+	if err := t.interrupted(fmt.Sprintf("save of %s", filename)); err != nil {
+		return "", err
+	}
 	folder := path.Dir(filename)
-	if _, err := os.Stat(folder); os.IsNotExist(err) {
+	if _, err := os.Stat(folder); os.IsNotExist(err) && t.writesOnDisk() {
 		if err = os.Mkdir(folder, 0777); err != nil {
 			return "", err
 		}
@@ -232,7 +257,11 @@ func saveToFile(filename string, object interface{}) (string, error) {
 		object = byteArray
 	}
 
-	return "", os.WriteFile(filename, object.([]byte), 0644)
+	if err := t.writeFile(filename, object.([]byte), 0644); err != nil {
+		return "", err
+	}
+	t.manifest.add(filename, t.currentFile, "")
+	return "", nil
 }
 
 func username() string {
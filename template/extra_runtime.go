@@ -2,13 +2,16 @@ package template
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
-	"path"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coveooss/gotemplate/v3/collections"
 	"github.com/coveooss/gotemplate/v3/utils"
@@ -21,19 +24,23 @@ const (
 )
 
 var runtimeFuncsArgs = arguments{
-	"alias":         {"name", "function", "source"},
-	"assert":        {"test", "message", "arguments"},
-	"assertWarning": {"test", "message", "arguments"},
-	"categories":    {"functionsGroups"},
-	"ellipsis":      {"function"},
-	"exec":          {"command"},
-	"exit":          {"exitValue"},
-	"func":          {"name", "function", "source", "config"},
-	"function":      {"name"},
-	"include":       {"source", "context"},
-	"localAlias":    {"name", "function", "source"},
-	"run":           {"command"},
-	"substitute":    {"content"},
+	"alias":          {"name", "function", "source"},
+	"aliasWith":      {"name", "function", "source", "config"},
+	"assert":         {"test", "message", "arguments"},
+	"assertWarning":  {"test", "message", "arguments"},
+	"categories":     {"functionsGroups"},
+	"ellipsis":       {"function"},
+	"exec":           {"command"},
+	"execWith":       {"options", "command"},
+	"exit":           {"exitValue"},
+	"func":           {"name", "function", "source", "config"},
+	"function":       {"name"},
+	"include":        {"source", "context"},
+	"localAlias":     {"name", "function", "source"},
+	"localAliasWith": {"name", "function", "source", "config"},
+	"run":            {"command"},
+	"runWith":        {"options", "command"},
+	"substitute":     {"content"},
 }
 
 var runtimeFuncsAliases = aliases{
@@ -49,7 +56,8 @@ var runtimeFuncsAliases = aliases{
 }
 
 var runtimeFuncsHelp = descriptions{
-	"alias":         "Defines an alias (go template function) using the function (exec, run, include, template). Executed in the context of the caller.",
+	"alias":         "Defines an alias (go template function) using the function (exec, run, include, template). Executed in the context of the caller. The extra arguments are supplied before the arguments of the caller.",
+	"aliasWith":     "Same as alias, but with a configuration of the alias (description, group, args, aliases, examples) supplied as a dictionary.",
 	"aliases":       "Returns the list of all functions that are simply an alias of another function.",
 	"allFunctions":  "Returns the list of all available functions.",
 	"assert":        "Raises a formatted error if the test condition is false.",
@@ -65,8 +73,19 @@ var runtimeFuncsHelp = descriptions{
 	"current":          "Returns the current folder (like pwd, but returns the folder of the currently running folder).",
 	"ellipsis":         "Returns the result of the function by expanding its last argument that must be an array into values. It's like calling function(arg1, arg2, otherArgs...).",
 	"exec":             "Returns the result of the shell command as structured data (as string if no other conversion is possible).",
-	"exit":             "Exits the current program execution.",
-	"func":             "Defines a function with the current context using the function (exec, run, include, template). Executed in the context of the caller.",
+	"execWith": strings.TrimSpace(collections.UnIndent(`
+		Same as exec, but with options supplied as a dictionary.
+
+		The supported options are:
+		    env          Environment variables added to (or replaced in) the current environment
+		    dir          Working folder of the command (relative to the current folder)
+		    stdin        Content sent to the standard input of the command
+		    timeout      Maximum duration of the command (i.e. "30s" or number of seconds)
+		    shell        Shell used to run the command if it is not directly executable
+		    allowFailure Returns {stdout, stderr, exitCode} instead of raising an error if the command fails
+	`)),
+	"exit": "Exits the current program execution.",
+	"func": "Defines a function with the current context using the function (exec, run, include, template). Executed in the context of the caller. The configuration could define the description, group, args (name type or dictionary with name, type, default, required and variadic, the calls are then validated against the types string, number, bool, list, dict or any), def (default values), aliases and examples.",
 	"function": strings.TrimSpace(collections.UnIndent(`
 		Returns the information relative to a specific function.
 
@@ -93,13 +112,15 @@ var runtimeFuncsHelp = descriptions{
 
 		This is similar to what the template action does but it allows you to capture its output in a variable.
 	`)),
-	"localAlias":    "Defines an alias (go template function) using the function (exec, run, include, template). Executed in the context of the function it maps to.",
-	"raise":         "Raise a formatted error.",
-	"run":           "Returns the result of the shell command as string.",
-	"substitute":    "Applies the supplied regex substitute specified on the command line on the supplied string (see --substitute).",
-	"templateNames": "Returns the list of available templates names.",
-	"templates":     "Returns the list of available templates.",
-	"userContext":   "Returns the user context (i.e. all global variables except the injected constant).",
+	"localAlias":     "Defines an alias (go template function) using the function (exec, run, include, template). Executed in the context of the function it maps to.",
+	"localAliasWith": "Same as localAlias, but with a configuration of the alias (see aliasWith).",
+	"raise":          "Raise a formatted error.",
+	"run":            "Returns the result of the shell command as string.",
+	"runWith":        "Same as run, but with options supplied as a dictionary (see execWith for the supported options).",
+	"substitute":     "Applies the supplied regex substitute specified on the command line on the supplied string (see --substitute).",
+	"templateNames":  "Returns the list of available templates names.",
+	"templates":      "Returns the list of available templates.",
+	"userContext":    "Returns the user context (i.e. all global variables except the injected constant).",
 }
 
 var runtimeFuncExamples = examples{
@@ -130,6 +151,7 @@ var runtimeFuncExamples = examples{
 func (t *Template) addRuntimeFuncs() {
 	var funcs = dictionary{
 		"alias":            t.alias,
+		"aliasWith":        t.aliasWith,
 		"aliases":          t.getAliases,
 		"allFunctions":     t.getAllFunctions,
 		"assert":           assertError,
@@ -139,6 +161,7 @@ func (t *Template) addRuntimeFuncs() {
 		"current":          t.current,
 		"ellipsis":         t.ellipsis,
 		"exec":             t.execCommand,
+		"execWith":         t.execCommandWith,
 		"exit":             exit,
 		"func":             t.defineFunc,
 		"function":         t.getFunction,
@@ -148,18 +171,21 @@ func (t *Template) addRuntimeFuncs() {
 		"getSignature":     getSignature,
 		"include":          t.include,
 		"localAlias":       t.localAlias,
+		"localAliasWith":   t.localAliasWith,
 		"raise":            raise,
 		"run":              t.runCommand,
+		"runWith":          t.runCommandWith,
 		"substitute":       t.substitute,
 		"templateNames":    t.getTemplateNames,
 		"templates":        t.Templates,
 		"userContext":      t.cloneUserContext,
 	}
 	t.AddFunctions(funcs, runtimeFunc, FuncOptions{
-		FuncHelp:     runtimeFuncsHelp,
-		FuncArgs:     runtimeFuncsArgs,
-		FuncAliases:  runtimeFuncsAliases,
-		FuncExamples: runtimeFuncExamples,
+		FuncHelp:      runtimeFuncsHelp,
+		FuncArgs:      runtimeFuncsArgs,
+		FuncAliases:   runtimeFuncsAliases,
+		FuncExamples:  runtimeFuncExamples,
+		FuncNamespace: "runtime",
 	})
 }
 
@@ -172,15 +198,23 @@ func (t *Template) cloneUserContext() interface{} {
 }
 
 func (t *Template) alias(name, function string, source interface{}, args ...interface{}) (string, error) {
-	return t.addAlias(name, function, source, false, false, args...)
+	return t.addAlias(name, function, source, false, false, nil, args...)
+}
+
+func (t *Template) aliasWith(name, function string, source, config interface{}, args ...interface{}) (string, error) {
+	return t.addAlias(name, function, source, false, false, config, args...)
 }
 
 func (t *Template) localAlias(name, function string, source interface{}, args ...interface{}) (string, error) {
-	return t.addAlias(name, function, source, true, false, args...)
+	return t.addAlias(name, function, source, true, false, nil, args...)
+}
+
+func (t *Template) localAliasWith(name, function string, source, config interface{}, args ...interface{}) (string, error) {
+	return t.addAlias(name, function, source, true, false, config, args...)
 }
 
 func (t *Template) defineFunc(name, function string, source, config interface{}) (string, error) {
-	return t.addAlias(name, function, source, true, true, config)
+	return t.addAlias(name, function, source, true, true, nil, config)
 }
 
 func (t *Template) execCommand(command interface{}, args ...interface{}) (interface{}, error) {
@@ -191,6 +225,22 @@ func (t *Template) runCommand(command interface{}, args ...interface{}) (interfa
 	return t.run(collections.Interface2string(command), args...)
 }
 
+func (t *Template) execCommandWith(options, command interface{}, args ...interface{}) (interface{}, error) {
+	commandOptions, err := newCommandOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return t.execWithOptions(commandOptions, collections.Interface2string(command), args...)
+}
+
+func (t *Template) runCommandWith(options, command interface{}, args ...interface{}) (interface{}, error) {
+	commandOptions, err := newCommandOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return t.runWithOptions(commandOptions, collections.Interface2string(command), args...)
+}
+
 func (t *Template) include(source interface{}, context ...interface{}) (interface{}, error) {
 	content, _, err := t.runTemplate(collections.Interface2string(source), context...)
 	if source == content {
@@ -200,7 +250,7 @@ func (t *Template) include(source interface{}, context ...interface{}) (interfac
 }
 
 // Define alias to an existing command
-func (t *Template) addAlias(name, function string, source interface{}, local, context bool, defaultArgs ...interface{}) (result string, err error) {
+func (t *Template) addAlias(name, function string, source interface{}, local, context bool, aliasConfig interface{}, defaultArgs ...interface{}) (result string, err error) {
 	for !local && t.parent != nil {
 		// local specifies if the alias should be executed in the context of the template where it is
 		// defined or in the context of the top parent
@@ -210,9 +260,14 @@ func (t *Template) addAlias(name, function string, source interface{}, local, co
 	f := t.run
 
 	switch function {
-	case "run":
-	case "exec":
-		f = t.exec
+	case "run", "exec":
+		if t.options[Sandbox] {
+			err = fmt.Errorf("alias %s using %s is not allowed in sandbox mode", name, function)
+			return
+		}
+		if function == "exec" {
+			f = t.exec
+		}
 	case "template", "include":
 		f = t.runTemplateItf
 	default:
@@ -220,13 +275,35 @@ func (t *Template) addAlias(name, function string, source interface{}, local, co
 		return
 	}
 
+	// Aliases could be defined concurrently when templates are processed in parallel
+	templateMutex.Lock()
+	defer templateMutex.Unlock()
+
 	if !context {
-		t.aliases[name] = &FuncInfo{
+		var config iDictionary
+		if aliasConfig != nil {
+			if config, err = collections.TryAsDictionary(aliasConfig); err != nil {
+				err = fmt.Errorf("alias configuration must be a dictionary: %[1]T %[1]v", aliasConfig)
+				return
+			}
+		}
+		fi := &FuncInfo{
 			function: func(args ...interface{}) (result interface{}, err error) {
+				if err = t.interrupted(fmt.Sprintf("alias %s", name)); err != nil {
+					return
+				}
 				return f(collections.Interface2string(source), append(defaultArgs, args...)...)
 			},
-			group: "User defined aliases",
+			group:     "User defined aliases",
+			namespace: extensionNamespace,
+			extension: true,
+		}
+		if config != nil {
+			if _, err = fi.configure(config, false); err != nil {
+				return
+			}
 		}
+		t.aliases[name] = fi
 		return
 	}
 
@@ -256,59 +333,21 @@ func (t *Template) addAlias(name, function string, source interface{}, local, co
 		return "", fmt.Errorf("too many parameters supplied")
 	}
 
-	for key, val := range config.AsMap() {
-		switch strings.ToLower(key) {
-		case "d", "desc", "description":
-			config.Set("description", val)
-		case "g", "group":
-			config.Set("group", val)
-		case "a", "args", "arguments":
-			switch val := val.(type) {
-			case iList:
-				config.Set("args", val)
-			default:
-				err = fmt.Errorf("%[1]s must be a list of strings: %[2]T %[2]v", key, val)
-				return
-			}
-		case "aliases":
-			switch val := val.(type) {
-			case iList:
-				config.Set("aliases", val)
-			default:
-				err = fmt.Errorf("%[1]s must be a list of strings: %[2]T %[2]v", key, val)
-				return
-			}
-		case "def", "default", "defaults":
-			switch val := val.(type) {
-			case iDictionary:
-				config.Set("def", val)
-			default:
-				err = fmt.Errorf("%s must be a dictionary: %T", key, val)
-				return
-			}
-		default:
-			return "", fmt.Errorf("unknown configuration %s", key)
-		}
-	}
-
-	emptyList := collections.CreateList()
 	fi := &FuncInfo{
-		name:        name,
-		group:       defval(config.Get("group"), "User defined functions").(string),
-		description: defval(config.Get("description"), "").(string),
-		arguments:   defval(config.Get("args"), emptyList).(iList).Strings(),
-		aliases:     defval(config.Get("aliases"), emptyList).(iList).Strings(),
+		name:      name,
+		group:     "User defined functions",
+		namespace: extensionNamespace,
+		extension: true,
 	}
-
-	defaultValues := defval(config.Get("def"), collections.CreateDictionary()).(iDictionary)
-
-	fi.in = strings.Join(fi.arguments, ", ")
-	for i := range fi.arguments {
-		// We only keep the arg name and get rid of any supplemental information (likely type)
-		fi.arguments[i] = strings.Fields(fi.arguments[i])[0]
+	defaultValues, err := fi.configure(config, true)
+	if err != nil {
+		return
 	}
 
 	fi.function = func(args ...interface{}) (result interface{}, err error) {
+		if err = t.interrupted(fmt.Sprintf("function %s", name)); err != nil {
+			return
+		}
 		context := collections.CreateDictionary()
 		parentContext := t.Context()
 		if parentContext.Len() == 0 {
@@ -320,6 +359,10 @@ func (t *Template) addAlias(name, function string, source interface{}, local, co
 			if len(fi.arguments) != 1 {
 				switch arg := args[0].(type) {
 				case string:
+					if len(fi.parameters) > 0 && fi.parameters[0].kind != "" {
+						// The string is the value of a typed argument, not a data structure to convert
+						break
+					}
 					var out interface{}
 					if collections.ConvertData(arg, &out) == nil {
 						args[0] = out
@@ -327,6 +370,10 @@ func (t *Template) addAlias(name, function string, source interface{}, local, co
 				}
 
 				if arg, err := collections.TryAsDictionary(args[0]); err == nil {
+					// The arguments are supplied by name
+					if err := fi.checkArguments(arg, 0); err != nil {
+						return nil, err
+					}
 					context.Merge(arg, defaultValues, parentContext)
 					break
 				}
@@ -339,13 +386,24 @@ func (t *Template) addAlias(name, function string, source interface{}, local, co
 			}
 
 			context.Merge(defaultValues, templateContext)
+			supplied, extra := collections.CreateDictionary(), 0
 			for i := range args {
 				if i >= len(fi.arguments) {
 					context.Set("ARGS", args[i:])
+					extra = len(args) - i
+					break
+				}
+				if i < len(fi.parameters) && fi.parameters[i].variadic {
+					supplied.Set(fi.arguments[i], args[i:])
+					context.Set(fi.arguments[i], args[i:])
 					break
 				}
+				supplied.Set(fi.arguments[i], args[i])
 				context.Set(fi.arguments[i], args[i])
 			}
+			if err := fi.checkArguments(supplied, extra); err != nil {
+				return nil, err
+			}
 		}
 		return f(collections.Interface2string(source), context)
 	}
@@ -354,8 +412,75 @@ func (t *Template) addAlias(name, function string, source interface{}, local, co
 	return
 }
 
+// Options supplied to execWith and runWith
+type commandOptions struct {
+	env          []string
+	dir          string
+	stdin        *string
+	timeout      time.Duration
+	shell        string
+	allowFailure bool
+}
+
+var commandOptionNames = []string{"env", "dir", "stdin", "timeout", "shell", "allowFailure"}
+
+func newCommandOptions(options interface{}) (result commandOptions, err error) {
+	dict, err := collections.TryAsDictionary(options)
+	if err != nil {
+		return result, fmt.Errorf("options must be a dictionary: %v", err)
+	}
+	for _, key := range dict.KeysAsString() {
+		value := dict.Get(key)
+		switch key.Str() {
+		case "env":
+			env, err := collections.TryAsDictionary(value)
+			if err != nil {
+				return result, fmt.Errorf("env must be a dictionary: %v", err)
+			}
+			for _, name := range env.KeysAsString() {
+				result.env = append(result.env, fmt.Sprintf("%s=%v", name, env.Get(name)))
+			}
+		case "dir":
+			result.dir = fmt.Sprint(value)
+		case "stdin":
+			stdin := fmt.Sprint(value)
+			result.stdin = &stdin
+		case "timeout":
+			if result.timeout, err = parseTimeout(value); err != nil {
+				return
+			}
+		case "shell":
+			result.shell = fmt.Sprint(value)
+		case "allowFailure":
+			if result.allowFailure, err = strconv.ParseBool(fmt.Sprint(value)); err != nil {
+				return result, fmt.Errorf("allowFailure must be a boolean: %v", value)
+			}
+		default:
+			return result, fmt.Errorf("unknown option %s (valid options are %s)", key, strings.Join(commandOptionNames, ", "))
+		}
+	}
+	return
+}
+
+// Convert a duration expressed as a string (i.e. 1m30s) or as a number of seconds.
+func parseTimeout(value interface{}) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(fmt.Sprint(value), 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	if duration, err := time.ParseDuration(fmt.Sprint(value)); err == nil {
+		return duration, nil
+	}
+	return 0, fmt.Errorf("invalid timeout %v (must be a duration or a number of seconds)", value)
+}
+
 // Execute the command (command could be a file, a template or a script)
 func (t *Template) run(command string, args ...interface{}) (result interface{}, err error) {
+	return t.runWithOptions(commandOptions{}, command, args...)
+}
+
+// Execute the command with the supplied options, the result is a dictionary containing stdout, stderr and exitCode
+// if allowFailure is set.
+func (t *Template) runWithOptions(options commandOptions, command string, args ...interface{}) (result interface{}, err error) {
 	var filename string
 
 	// We check if the supplied command is a template
@@ -372,11 +497,17 @@ func (t *Template) run(command string, args ...interface{}) (result interface{},
 	}
 
 	var cmd *exec.Cmd
+	ctx := t.getRunContext()
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
 	if filename != "" {
-		cmd, err = utils.GetCommandFromFile(filename, args...)
+		cmd, err = utils.GetCommandFromFileContext(ctx, filename, args...)
 	} else {
 		var tempFile string
-		cmd, tempFile, err = utils.GetCommandFromString(command, args...)
+		cmd, tempFile, err = utils.GetCommandFromStringWithShell(ctx, options.shell, command, args...)
 		if tempFile != "" {
 			defer func() { os.Remove(tempFile) }()
 		}
@@ -388,12 +519,33 @@ func (t *Template) run(command string, args ...interface{}) (result interface{},
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdin = os.Stdin
+	if options.stdin != nil {
+		cmd.Stdin = strings.NewReader(*options.stdin)
+	}
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	cmd.Dir = t.folder
+	if options.dir != "" {
+		cmd.Dir = iif(filepath.IsAbs(options.dir), options.dir, filepath.Join(t.folder, options.dir)).(string)
+	}
+	if len(options.env) > 0 {
+		// The last value of a variable takes precedence
+		cmd.Env = append(os.Environ(), options.env...)
+	}
 	InternalLog.Infoln("Launching", cmd.Args, "in", cmd.Dir)
 
-	if err = cmd.Run(); err == nil {
+	err = cmd.Run()
+	var exitError *exec.ExitError
+	if ctx.Err() != nil {
+		err = fmt.Errorf("command %q interrupted: %w", command, ctx.Err())
+	} else if options.allowFailure && (err == nil || errors.As(err, &exitError)) {
+		exitCode := 0
+		if exitError != nil {
+			exitCode = exitError.ExitCode()
+		}
+		result = collections.CreateDictionary().Set("stdout", stdout.String()).Set("stderr", stderr.String()).Set("exitCode", exitCode)
+		err = nil
+	} else if err == nil {
 		result = stdout.String()
 		InternalLog.Print(stderr.String())
 	} else {
@@ -414,11 +566,24 @@ func (t *Template) tryConvert(value string) interface{} {
 
 // Execute the command (command could be a file, a template or a script) and convert its result as data if possible
 func (t *Template) exec(command string, args ...interface{}) (interface{}, error) {
-	commandOutput, err := t.run(command, args...)
+	return t.execWithOptions(commandOptions{}, command, args...)
+}
+
+func (t *Template) execWithOptions(options commandOptions, command string, args ...interface{}) (interface{}, error) {
+	commandOutput, err := t.runWithOptions(options, command, args...)
 	if err != nil || commandOutput == nil {
 		return commandOutput, err
 	}
 
+	if result, isDict := commandOutput.(collections.IDictionary); isDict {
+		// The command has been executed with allowFailure, only the output is converted
+		var parsedOutput interface{}
+		if collections.ConvertData(result.Get("stdout").(string), &parsedOutput) == nil {
+			result.Set("stdout", parsedOutput)
+		}
+		return result, nil
+	}
+
 	var parsedOutput interface{}
 	err = collections.ConvertData(commandOutput.(string), &parsedOutput)
 
@@ -437,6 +602,9 @@ func optimizedRunTemplate(t *Template, withClone bool, source string, args ...in
 	if source == "" {
 		return
 	}
+	if err = t.interrupted(fmt.Sprintf("template %s", source)); err != nil {
+		return
+	}
 	var out bytes.Buffer
 
 	parentContext := t.context
@@ -470,10 +638,13 @@ func optimizedRunTemplate(t *Template, withClone bool, source string, args ...in
 		// This is not a template, so we try to load file named <source>
 		if !strings.Contains(source, "\n") {
 			tryFile := source
-			if !path.IsAbs(tryFile) {
-				tryFile = path.Join(t.folder, tryFile)
+			if !filepath.IsAbs(tryFile) {
+				tryFile = filepath.Join(t.folder, tryFile)
+			}
+			if err = t.checkSandboxInclude(tryFile); err != nil {
+				return
 			}
-			if fileContent, e := os.ReadFile(tryFile); e != nil {
+			if fileContent, e := t.readFile(tryFile); e != nil {
 				if _, ok := e.(*os.PathError); !ok {
 					err = e
 					return
@@ -487,7 +658,9 @@ func optimizedRunTemplate(t *Template, withClone bool, source string, args ...in
 		source = string(razor)
 
 		// There is no file named <source>, so we consider that <source> is the content
-		inline, e := t.New("inline").Parse(source)
+		templateMutex.Lock()
+		inline, e := t.parseCode(t.New("inline"), source)
+		templateMutex.Unlock()
 		if e != nil {
 			err = e
 			return
@@ -1,6 +1,8 @@
 package template
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/coveooss/gotemplate/v3/collections"
@@ -196,3 +198,43 @@ func TestExec(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandWithOptions(t *testing.T) {
+	folder := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(folder, "sub"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "shell.sh"), []byte("#!/bin/sh\necho custom $(tail -n 1 \"$1\")\n"), 0755))
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		err      string
+	}{
+		{"Env", `{{ runWith (dict "env" (dict "GREETING" "hello")) "echo $GREETING" }}`, "hello\n", ""},
+		{"Dir", `@base(trim(runWith(dict("dir", "sub"), "pwd")))`, "sub", ""},
+		{"Stdin", `@runWith(dict("stdin", "from stdin"), "cat")`, "from stdin", ""},
+		{"Shell", `@runWith(dict("shell", "./shell.sh"), "echo hello")`, "custom echo hello\n", ""},
+		{"Allow failure", `{{- $result := runWith (dict "allowFailure" true) "echo out; echo err >&2; exit 3" }}{{ $result.exitCode }} {{ trim $result.stdout }} {{ trim $result.stderr }}`, "3 out err", ""},
+		{"Allow failure success", `@runWith(dict("allowFailure", true), "echo out").exitCode`, "0", ""},
+		{"Exec with data", `@execWith(dict("allowFailure", true), "echo 'a: 1'").stdout.a`, "1", ""},
+		{"Failure", `@runWith(dict(), "exit 3")`, "", "exit status 3"},
+		{"Timeout", `@runWith(dict("timeout", "100ms"), "sleep 10")`, "", `command "sleep 10" interrupted: context deadline exceeded`},
+		{"Invalid timeout", `@runWith(dict("timeout", "soon"), "echo")`, "", "invalid timeout soon (must be a duration or a number of seconds)"},
+		{"Unknown option", `@runWith(dict("cwd", "sub"), "echo")`, "", "unknown option cwd (valid options are env, dir, stdin, timeout, shell, allowFailure)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			template := MustNewTemplate(folder, nil, "", nil)
+			result, err := template.ProcessContent(test.content, filepath.Join(folder, "test.gt"))
+			if test.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), test.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
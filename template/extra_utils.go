@@ -125,9 +125,10 @@ var utilsFuncsHelp = descriptions{
 
 func (t *Template) addUtilsFuncs() {
 	t.AddFunctions(utilsFuncs, utilsBase, FuncOptions{
-		FuncHelp:    utilsFuncsHelp,
-		FuncArgs:    utilsFuncsArgs,
-		FuncAliases: utilsFuncsAliases,
+		FuncHelp:      utilsFuncsHelp,
+		FuncArgs:      utilsFuncsArgs,
+		FuncAliases:   utilsFuncsAliases,
+		FuncNamespace: "utils",
 	})
 }
 
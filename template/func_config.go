@@ -0,0 +1,301 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+)
+
+// The keys accepted in the configuration of the functions defined by func and aliasWith (with their canonical name).
+var funcConfigKeys = map[string]string{
+	"d":           "description",
+	"desc":        "description",
+	"description": "description",
+	"g":           "group",
+	"group":       "group",
+	"a":           "args",
+	"args":        "args",
+	"arguments":   "args",
+	"aliases":     "aliases",
+	"e":           "examples",
+	"examples":    "examples",
+	"def":         "def",
+	"default":     "def",
+	"defaults":    "def",
+}
+
+// The types that could be declared for the arguments of the functions defined by func.
+var (
+	parameterKindNames = []string{"string", "number", "bool", "list", "dict", "any"}
+	parameterKinds     = map[string]bool{"string": true, "number": true, "bool": true, "list": true, "dict": true, "any": true}
+)
+
+// Parameter of a function defined by func, used to validate the arguments supplied by the caller.
+type funcParameter struct {
+	name     string
+	kind     string
+	required bool
+	variadic bool
+}
+
+func (p funcParameter) String() string {
+	return p.name + " " + iif(p.variadic, "...", "").(string) + p.kind
+}
+
+// Returns an error if the value does not match the type of the parameter.
+func (p funcParameter) check(function string, value interface{}) error {
+	if p.variadic {
+		list, err := collections.TryAsList(value)
+		if err != nil {
+			return fmt.Errorf("argument %s of %s must be a list of %s: %T %v", p.name, function, p.kind, value, value)
+		}
+		for i, item := range list.AsArray() {
+			if !isKind(p.kind, item) {
+				return fmt.Errorf("argument %s[%d] of %s must be a %s: %T %v", p.name, i, function, p.kind, item, item)
+			}
+		}
+		return nil
+	}
+	if !isKind(p.kind, value) {
+		return fmt.Errorf("argument %s of %s must be a %s: %T %v", p.name, function, p.kind, value, value)
+	}
+	return nil
+}
+
+func isKind(kind string, value interface{}) bool {
+	if kind == "any" {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.String:
+		return kind == "string"
+	case reflect.Bool:
+		return kind == "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return kind == "number"
+	case reflect.Slice, reflect.Array:
+		return kind == "list"
+	case reflect.Map:
+		return kind == "dict"
+	}
+	switch value.(type) {
+	case iList:
+		return kind == "list"
+	case iDictionary:
+		return kind == "dict"
+	}
+	return false
+}
+
+// Validates the arguments supplied to the function (by position or by name) against its parameters.
+func (fi *FuncInfo) checkArguments(supplied iDictionary, extra int) error {
+	if fi.parameters == nil {
+		return nil
+	}
+	if extra > 0 {
+		return fmt.Errorf("too many arguments in call to %s (%d expected, got %d)", fi.name, len(fi.parameters), len(fi.parameters)+extra)
+	}
+	for _, parameter := range fi.parameters {
+		if !supplied.Has(parameter.name) {
+			if parameter.required {
+				return fmt.Errorf("missing required argument %s in call to %s", parameter.name, fi.name)
+			}
+			continue
+		}
+		if err := parameter.check(fi.name, supplied.Get(parameter.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Applies the configuration supplied to func or aliasWith to the function definition. The default values of the
+// arguments are returned if they are allowed.
+func (fi *FuncInfo) configure(config iDictionary, withDefaults bool) (defaults iDictionary, err error) {
+	defaults = collections.CreateDictionary()
+	var signature []string
+	var args, explicitDefaults iDictionary
+	for key, val := range config.AsMap() {
+		canonical := funcConfigKeys[strings.ToLower(key)]
+		if canonical == "def" && !withDefaults {
+			canonical = ""
+		}
+		switch canonical {
+		case "description":
+			fi.description = fmt.Sprint(val)
+		case "group":
+			fi.group = fmt.Sprint(val)
+		case "args":
+			if signature, args, err = fi.configureArguments(key, val); err != nil {
+				return
+			}
+		case "aliases":
+			list, isList := val.(iList)
+			if !isList {
+				return nil, fmt.Errorf("%[1]s must be a list of strings: %[2]T %[2]v", key, val)
+			}
+			fi.aliases = list.Strings()
+		case "examples":
+			if fi.examples, err = parseExamples(key, val); err != nil {
+				return
+			}
+		case "def":
+			var isDict bool
+			if explicitDefaults, isDict = val.(iDictionary); !isDict {
+				return nil, fmt.Errorf("%s must be a dictionary: %T", key, val)
+			}
+		default:
+			return nil, fmt.Errorf("unknown configuration %s", key)
+		}
+	}
+
+	if explicitDefaults != nil {
+		defaults.Merge(explicitDefaults)
+	}
+	if args != nil {
+		defaults.Merge(args)
+	}
+	if !withDefaults {
+		for _, parameter := range fi.parameters {
+			if parameter.required || parameter.variadic || defaults.Has(parameter.name) {
+				return nil, fmt.Errorf("only the name and the type of the arguments are supported by alias (%s)", parameter.name)
+			}
+		}
+		// The arguments of the aliases are only informative
+		fi.parameters = nil
+	}
+	for _, parameter := range fi.parameters {
+		if defaults.Has(parameter.name) {
+			if err = parameter.check(fi.name, defaults.Get(parameter.name)); err != nil {
+				return nil, fmt.Errorf("invalid default value: %w", err)
+			}
+		}
+	}
+	// The signature shows the type and the default value of the arguments
+	for i, name := range fi.arguments {
+		if defaults.Has(name) {
+			signature[i] += " = " + formatDefault(defaults.Get(name))
+		}
+	}
+	fi.in = strings.Join(signature, ", ")
+	return
+}
+
+// Processes the arguments definition that could either be "name [type]" or a dictionary with the name, type,
+// default value, required and variadic attributes of the argument. The arguments defined with dictionaries are
+// validated when the function is called. The signature of each argument and the default values are returned.
+func (fi *FuncInfo) configureArguments(key string, value interface{}) (signature []string, defaults iDictionary, err error) {
+	list, isList := value.(iList)
+	if !isList {
+		return nil, nil, fmt.Errorf("%[1]s must be a list of strings or dictionaries: %[2]T %[2]v", key, value)
+	}
+	defaults = collections.CreateDictionary()
+	var parameters []funcParameter
+	var withSchema bool
+	for i, arg := range list.AsArray() {
+		if arg, isString := arg.(string); isString {
+			fields := strings.Fields(arg)
+			if len(fields) == 0 {
+				return nil, nil, fmt.Errorf("%s cannot contain empty argument", key)
+			}
+			// We only keep the arg name and get rid of any supplemental information (likely type)
+			fi.arguments = append(fi.arguments, fields[0])
+			signature = append(signature, strings.Join(fields, " "))
+			parameters = append(parameters, funcParameter{name: fields[0], kind: "any"})
+			continue
+		}
+		definition, err := collections.TryAsDictionary(arg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%[1]s must be a list of strings or dictionaries: %[2]T %[2]v", key, arg)
+		}
+		name := strings.TrimSpace(fmt.Sprint(definition.Get("name")))
+		if !definition.Has("name") || name == "" {
+			return nil, nil, fmt.Errorf("%s contains an argument without name", key)
+		}
+		parameter := funcParameter{name: name, kind: strings.ToLower(fmt.Sprint(defval(definition.Get("type"), "any")))}
+		for _, option := range definition.KeysAsString() {
+			switch option {
+			case "name", "type":
+			case "default":
+				defaults.Set(name, definition.Get("default"))
+			case "required", "variadic":
+				flag, err := strconv.ParseBool(fmt.Sprint(definition.Get(option)))
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s of argument %s must be a boolean: %v", option, name, definition.Get(option))
+				}
+				if option == "required" {
+					parameter.required = flag
+				} else {
+					parameter.variadic = flag
+				}
+			default:
+				return nil, nil, fmt.Errorf("unknown configuration %s for argument %s", option, name)
+			}
+		}
+		if !parameterKinds[parameter.kind] {
+			return nil, nil, fmt.Errorf("invalid type %s for argument %s (valid types are %s)", parameter.kind, name, strings.Join(parameterKindNames, ", "))
+		}
+		if parameter.variadic && i != list.Len()-1 {
+			return nil, nil, fmt.Errorf("only the last argument can be variadic (%s)", name)
+		}
+		if parameter.required && defaults.Has(name) {
+			return nil, nil, fmt.Errorf("argument %s cannot be required and have a default value", name)
+		}
+		fi.arguments = append(fi.arguments, name)
+		signature = append(signature, parameter.String())
+		parameters = append(parameters, parameter)
+		withSchema = true
+	}
+	if withSchema {
+		fi.parameters = parameters
+	}
+	return
+}
+
+// Returns the examples defined either as razor expressions or as dictionaries (razor, template, result).
+func parseExamples(key string, value interface{}) (result []Example, err error) {
+	list, isList := value.(iList)
+	if !isList {
+		return nil, fmt.Errorf("%[1]s must be a list of strings or dictionaries: %[2]T %[2]v", key, value)
+	}
+	for _, item := range list.AsArray() {
+		if razor, isString := item.(string); isString {
+			result = append(result, Example{Razor: razor})
+			continue
+		}
+		definition, err := collections.TryAsDictionary(item)
+		if err != nil {
+			return nil, fmt.Errorf("%[1]s must be a list of strings or dictionaries: %[2]T %[2]v", key, item)
+		}
+		var example Example
+		for _, name := range definition.KeysAsString() {
+			value := fmt.Sprint(definition.Get(name))
+			switch name {
+			case "razor":
+				example.Razor = value
+			case "template":
+				example.Template = value
+			case "result":
+				example.Result = value
+			default:
+				return nil, fmt.Errorf("unknown configuration %s for example (only razor, template and result are supported)", name)
+			}
+		}
+		result = append(result, example)
+	}
+	return
+}
+
+func formatDefault(value interface{}) string {
+	if value, isString := value.(string); isString {
+		return fmt.Sprintf("%q", value)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,125 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuncConfig(t *testing.T) {
+	folder := t.TempDir()
+	extension := `@define("greet")Hello @.name@.punct@end
+@{config := dict("description", "Say hello", "group", "Greetings", "aliases", list("hi"))}
+@{_ := set($config, "args", list(dict("name", "name", "type", "string"), dict("name", "punct", "type", "string", "default", "!")))}
+@{_ := set($config, "examples", list(` + "`" + `@greet("world")` + "`" + `, dict("razor", ` + "`" + `@hi("you", "?")` + "`" + `)))}
+@func("greet", "template", "greet", $config)
+@aliasWith("shout", "template", "greet", dict("description", "Shout", "group", "Greetings", "args", list("context dict")))
+@alias("welcome", "template", "greet", dict("name", "world", "punct", "."))
+@alias("describe", "template", "@.description", dict("description", "Not a configuration"))`
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "ext.gte"), []byte(extension), 0644))
+	template := MustNewTemplate(folder, nil, "", nil)
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"Call with default", `@greet("Bob")`, "Hello Bob!"},
+		{"Call alias", `@hi("Al", "?")`, "Hello Al?"},
+		{"Alias with config", `@shout(dict("name", "Z", "punct", "."))`, "Hello Z."},
+		{"Alias with default argument", `@welcome()`, "Hello world."},
+		{"Alias argument looking like a configuration", `@describe()`, "Not a configuration"},
+		{"Arguments", `{{ (function "greet").Arguments }}`, `name string, punct string = "!"`},
+		{"Alias arguments", `{{ (function "shout").Arguments }} {{ (function "shout").Description }}`, "context dict Shout"},
+		{"Default group", `{{ (function "welcome").Group }}`, "User defined aliases"},
+		{"Categories", `{{ range categories }}{{ if eq .Name "Greetings" }}{{ .Functions }}{{ end }}{{ end }}`, "[greet hi shout]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := template.ProcessContent(tt.content, "test.gt")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, function := range template.DescribeFunctions(false, "greet") {
+		if function.Name == "greet" {
+			assert.Equal(t, []string{"hi"}, function.Aliases)
+			assert.Equal(t, []Example{
+				{Razor: `@greet("world")`, Template: `{{ greet "world" }}`, Result: "Hello world!"},
+				{Razor: `@hi("you", "?")`, Template: `{{ hi "you" "?" }}`, Result: "Hello you?"},
+			}, function.Examples, "Examples are completed")
+		}
+	}
+}
+
+func TestFuncConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"Unknown key", `@func("f", "template", "x", dict("unknown", 1))`, "unknown configuration unknown"},
+		{"Invalid args", `@func("f", "template", "x", dict("args", 1))`, "args must be a list of strings or dictionaries: int 1"},
+		{"Argument without name", `@func("f", "template", "x", dict("args", list(dict("type", "int"))))`, "args contains an argument without name"},
+		{"Unknown argument key", `@func("f", "template", "x", dict("args", list(dict("name", "a", "size", 1))))`, "unknown configuration size for argument a"},
+		{"Invalid example", `@func("f", "template", "x", dict("examples", list(dict("output", 1))))`, "unknown configuration output for example"},
+		{"Alias with default", `@aliasWith("f", "template", "x", dict("args", list(dict("name", "a", "default", 1))))`, "only the name and the type of the arguments are supported by alias (a)"},
+		{"Invalid alias configuration", `@aliasWith("f", "template", "x", 1)`, "alias configuration must be a dictionary: int 1"},
+		{"Invalid type", `@func("f", "template", "x", dict("args", list(dict("name", "a", "type", "int"))))`, "invalid type int for argument a (valid types are string, number, bool, list, dict, any)"},
+		{"Variadic not last", `@func("f", "template", "x", dict("args", list(dict("name", "a", "variadic", true), "b")))`, "only the last argument can be variadic (a)"},
+		{"Invalid required", `@func("f", "template", "x", dict("args", list(dict("name", "a", "required", "maybe"))))`, "required of argument a must be a boolean: maybe"},
+		{"Required with default", `@func("f", "template", "x", dict("args", list(dict("name", "a", "required", true, "default", 1))))`, "argument a cannot be required and have a default value"},
+		{"Invalid default", `@func("f", "template", "x", dict("args", list(dict("name", "a", "type", "bool", "default", 1))))`, "invalid default value: argument a of f must be a bool: int 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MustNewTemplate(t.TempDir(), nil, "", nil).ProcessContent(tt.content, "test.gt")
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestFuncParameters(t *testing.T) {
+	folder := t.TempDir()
+	extension := `@define("sum")@.title: @(add(.values...))@end
+@{args := list(dict("name", "title", "type", "string", "required", true), dict("name", "values", "type", "number", "variadic", true))}
+@func("total", "template", "sum", dict("args", $args))
+@func("flag", "template", "@.enabled @.options", dict("args", list(dict("name", "enabled", "type", "bool", "default", false), "options")))
+@func("heading", "template", "@.text", dict("args", list(dict("name", "text", "type", "string"), "level")))`
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "ext.gte"), []byte(extension), 0644))
+	template := MustNewTemplate(folder, nil, "", nil)
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{"Positional", `@total("A", 1, 2, 3)`, "A: 6", ""},
+		{"Single string argument", `@heading("123")`, "123", ""},
+		{"Named", `@total(dict("title", "B", "values", list(4, 5)))`, "B: 9", ""},
+		{"Default", `@flag(dict("options", "x"))`, "false x", ""},
+		{"Untyped argument", `@flag(true, list(1))`, "true [1]", ""},
+		{"Signature", `{{ (function "total").Arguments }}`, "title string, values ...number", ""},
+		{"Missing required", "\n{{ total }}", "", "test.gt:2:3: error calling total: missing required argument title in call to total"},
+		{"Missing required by name", `@total(dict("values", list(1)))`, "", "missing required argument title in call to total"},
+		{"Invalid type", `@total(3, 1)`, "", "argument title of total must be a string: int 3"},
+		{"Invalid variadic", `@total("x", 1, "2")`, "", "argument values[1] of total must be a number: string 2"},
+		{"Invalid list", `@total(dict("title", "x", "values", 1))`, "", "argument values of total must be a list of number: int 1"},
+		{"Too many arguments", `@flag(true, 1, 2)`, "", "too many arguments in call to flag (2 expected, got 3)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := template.ProcessContent(tt.content, "test.gt")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
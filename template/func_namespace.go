@@ -0,0 +1,183 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Namespace of the functions defined by alias and func in the extension files.
+const extensionNamespace = "ext"
+
+// The collisions already reported.
+var reportedCollisions sync.Map
+
+var reInvalidNamespace = regexp.MustCompile(`[^\p{L}\d_]+`)
+
+// Returns a valid namespace identifier from the supplied name.
+func toNamespace(name string) string { return reInvalidNamespace.ReplaceAllString(name, "_") }
+
+// Returns the name under which the namespaced function is registered in go template.
+func namespacedName(namespace, name string) string { return namespace + "_" + name }
+
+func lowerFirst(name string) string {
+	for i, r := range name {
+		return string(unicode.ToLower(r)) + name[i+utf8.RuneLen(r):]
+	}
+	return name
+}
+
+// Indicates if the new function should replace the existing one for the bare name.
+func (t *Template) takesPrecedence(function, existing *FuncInfo) bool {
+	if existing == nil || function.extension == existing.extension {
+		// Without collision between a built-in and an extension function, the last definition wins
+		return true
+	}
+	// The extensions override the built-in functions unless the built-in functions are explicitly preferred
+	preferred := function.extension != t.options[PreferBuiltins]
+	extension := function
+	if !function.extension {
+		extension = existing
+	}
+	key := extension.namespace + "." + extension.name
+	if _, reported := reportedCollisions.LoadOrStore(key, true); !reported {
+		used, other := function, existing
+		if !preferred {
+			used, other = existing, function
+		}
+		message := fmt.Sprintf("Function %s defined in %s collides with a built-in function, the %s version is used", extension.name, extension.namespace, iif(used == extension, "extension", "built-in"))
+		if other.namespace != "" {
+			message += fmt.Sprintf(" (the other one remains available as %s.%s)", other.namespace, other.name)
+		}
+		InternalLog.Warning(message)
+	}
+	return preferred
+}
+
+// Returns the function registered in the namespace (template mutex must be locked). The first letter of the function
+// could be capitalized (i.e. math.Pow for math.pow).
+func (t *Template) getNamespacedFunction(namespace, name string) *FuncInfo {
+	if function := t.namespaces[namespace][name]; function != nil {
+		return function
+	}
+	return t.namespaces[namespace][lowerFirst(name)]
+}
+
+// Parses the code into the supplied template (template mutex must be locked). The namespaces are known by the parser
+// to accept the namespaced function calls (i.e. sprig.trunc), but they are not functions: the calls are replaced by
+// the actual functions and the other references to the namespaces are reported by resolveNamespaces.
+func (t *Template) parseCode(tpl *template.Template, code string) (*template.Template, error) {
+	known := make(map[string]interface{}, len(t.functions))
+	for name := range t.functions {
+		known[name] = true
+	}
+	for namespace, functions := range t.namespaces {
+		known[namespace] = true
+		for name := range functions {
+			known[namespacedName(namespace, name)] = true
+		}
+	}
+
+	trees := make(map[string]*parse.Tree)
+	if _, err := parse.New(tpl.Name()).Parse(code, t.LeftDelim(), t.RightDelim(), trees, known); err != nil {
+		return nil, err
+	}
+	if err := t.resolveNamespaces(trees); err != nil {
+		return nil, err
+	}
+	for name, tree := range trees {
+		if _, err := tpl.AddParseTree(name, tree); err != nil {
+			return nil, err
+		}
+	}
+	return tpl, nil
+}
+
+// Replaces the namespaced function calls (i.e. sprig.trunc) by the actual functions in the parsed templates and
+// reports the namespaces used as functions (template mutex must be locked).
+func (t *Template) resolveNamespaces(trees map[string]*parse.Tree) error {
+	for _, tree := range trees {
+		if _, err := t.resolveNode(tree, tree.Root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Template) resolveNode(tree *parse.Tree, node parse.Node) (parse.Node, error) {
+	var err error
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			break
+		}
+		for i := range n.Nodes {
+			if n.Nodes[i], err = t.resolveNode(tree, n.Nodes[i]); err != nil {
+				return node, err
+			}
+		}
+	case *parse.ActionNode:
+		_, err = t.resolveNode(tree, n.Pipe)
+	case *parse.IfNode:
+		err = t.resolveBranch(tree, &n.BranchNode)
+	case *parse.RangeNode:
+		err = t.resolveBranch(tree, &n.BranchNode)
+	case *parse.WithNode:
+		err = t.resolveBranch(tree, &n.BranchNode)
+	case *parse.TemplateNode:
+		_, err = t.resolveNode(tree, n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			break
+		}
+		for _, command := range n.Cmds {
+			for i := range command.Args {
+				if command.Args[i], err = t.resolveNode(tree, command.Args[i]); err != nil {
+					return node, err
+				}
+			}
+		}
+	case *parse.ChainNode:
+		// Go template syntax: namespace.function
+		if identifier, isIdentifier := n.Node.(*parse.IdentifierNode); isIdentifier && len(n.Field) == 1 && t.namespaces[identifier.Ident] != nil {
+			if function := t.getNamespacedFunction(identifier.Ident, n.Field[0]); function != nil {
+				return parse.NewIdentifier(namespacedName(identifier.Ident, function.name)).SetTree(tree).SetPos(n.Pos), nil
+			}
+			if t.functions[identifier.Ident] == nil {
+				// The identifier is not a real function, so the selection cannot be applied on its result
+				location, _ := tree.ErrorContext(n)
+				return node, fmt.Errorf("template: %s: function %q not defined", location, identifier.Ident+"."+n.Field[0])
+			}
+		}
+		n.Node, err = t.resolveNode(tree, n.Node)
+	case *parse.VariableNode:
+		// Razor syntax: the namespaced function calls are converted as method calls on a global variable ($.namespace.function)
+		if len(n.Ident) == 3 && n.Ident[0] == "$" && t.namespaces[n.Ident[1]] != nil {
+			if function := t.getNamespacedFunction(n.Ident[1], n.Ident[2]); function != nil {
+				return parse.NewIdentifier(namespacedName(n.Ident[1], function.name)).SetTree(tree).SetPos(n.Pos), nil
+			}
+		}
+	case *parse.IdentifierNode:
+		if t.namespaces[n.Ident] != nil && t.functions[n.Ident] == nil {
+			// The namespace is only known by the parser to accept namespace.function
+			location, _ := tree.ErrorContext(n)
+			return node, fmt.Errorf("template: %s: function %q not defined", location, n.Ident)
+		}
+	}
+	return node, err
+}
+
+func (t *Template) resolveBranch(tree *parse.Tree, branch *parse.BranchNode) (err error) {
+	if _, err = t.resolveNode(tree, branch.Pipe); err != nil {
+		return
+	}
+	if _, err = t.resolveNode(tree, branch.List); err != nil {
+		return
+	}
+	_, err = t.resolveNode(tree, branch.ElseList)
+	return
+}
@@ -0,0 +1,76 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaces(t *testing.T) {
+	template := MustNewTemplate(t.TempDir(), nil, "", nil)
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{"Razor", `@sprig.trunc(3, "hello")`, "hel", ""},
+		{"Razor capitalized", `@math.Pow(2, 3)`, "8", ""},
+		{"Razor expression", `@(math.pow(2, 3) + 1)`, "9", ""},
+		{"Several calls", `@sprig.upper("a") @math.Pow(2, 2)`, "A 4", ""},
+		{"Go template", `{{ sprig.truncSprig 2 "abc" }}`, "ab", ""},
+		{"Namespace is also a function", `{{ data.toYaml 1 }}{{ (data "a: 1").a }}`, "1\n1", ""},
+		{"Namespace is not a function", `{{ index git 0 }}`, "", `function "git" not defined`},
+		{"In sub template", `{{ define "sub" }}{{ math.pow 2 . }}{{ end }}{{ template "sub" 4 }}`, "16", ""},
+		{"Function info", `{{ (function "sprig.trunc").RealName }} {{ (function "math.Pow").Group }}`, "truncSprig Mathematic Fundamental", ""},
+		{"Unknown function", `{{ math.foo 2 }}`, "", `function "math.foo" not defined`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := template.ProcessContent(tt.content, "test.gt")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNamespacesPrecedence(t *testing.T) {
+	folder := t.TempDir()
+	extension := `@func("upper", "include", "overridden @.s", dict("args", list("s")))`
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "ext.gte"), []byte(extension), 0644))
+	content := `@upper("a") @ext.upper("b") @sprig.upper("c")`
+
+	for _, tt := range []struct {
+		options OptionsSet
+		want    string
+	}{
+		{DefaultOptions(), "overridden a overridden b C"},
+		{DefaultOptions().Set(PreferBuiltins), "A overridden b C"},
+	} {
+		template := MustNewTemplate(folder, nil, "", tt.options)
+		got, err := template.GetNewContext(folder, false).ProcessContent(content, "test.gt")
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+}
+
+func TestNamespacesAreNotShared(t *testing.T) {
+	folder := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "ext.gte"), []byte(`@alias("greet", "template", "hello")@define("hello")hello@end`), 0644))
+	extended := MustNewTemplate(folder, nil, "", nil)
+	got, err := extended.ProcessContent(`@ext.greet()`, "test.gt")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", got)
+
+	// Without the extension, ext.greet refers to the global variable
+	other := MustNewTemplate(t.TempDir(), map[string]interface{}{"ext": map[string]interface{}{"greet": "hi"}}, "", nil)
+	got, err = other.ProcessContent(`@ext.greet()`, "test.gt")
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", got)
+}
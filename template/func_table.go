@@ -22,13 +22,16 @@ type FuncInfo struct {
 	in, out     string
 	alias       *FuncInfo
 	examples    []Example
+	parameters  []funcParameter
+	namespace   string
+	extension   bool
 }
 
 // Example can be added to a function to describe how to use it.
 type Example struct {
-	Razor    string
-	Template string
-	Result   string
+	Razor    string `json:"razor,omitempty" yaml:"razor,omitempty"`
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+	Result   string `json:"result,omitempty" yaml:"result,omitempty"`
 }
 
 func (e Example) String() (result string) {
@@ -60,6 +63,9 @@ func (fi FuncInfo) Group() string { return ifUndef(&fi, fi.alias).(*FuncInfo).gr
 // Name returns the name related to the entry.
 func (fi FuncInfo) Name() string { return fi.name }
 
+// Namespace returns the namespace through which the function could also be called (i.e. sprig for sprig.trunc).
+func (fi FuncInfo) Namespace() string { return fi.namespace }
+
 // Signature returns the function signature.
 func (fi FuncInfo) Signature() string { return fi.getSignature(false) }
 
@@ -165,6 +171,14 @@ func (fi FuncInfo) Result() string {
 
 type funcTableMap map[string]*FuncInfo
 
+func (ftm funcTableMap) clone() funcTableMap {
+	result := make(funcTableMap, len(ftm))
+	for key, val := range ftm {
+		result[key] = val
+	}
+	return result
+}
+
 func (ftm funcTableMap) convert() template.FuncMap {
 	result := collections.CreateDictionary(len(ftm))
 	for key, val := range ftm {
@@ -190,6 +204,8 @@ const (
 	FuncGroup
 	// FuncExamples is used to associate examples (from razor to template to result) to functions added to go templates.
 	FuncExamples
+	// FuncNamespace is used to define the namespace through which the functions could also be called (i.e. namespace.function).
+	FuncNamespace
 )
 
 // FuncOptions is a map of FuncOptionsSet that is used to associates help, aliases, arguments and groups to functions added to go template.
@@ -210,6 +226,7 @@ func (t *Template) AddFunctions(funcs dictionary, group string, options FuncOpti
 	arguments := defval(options[FuncArgs], arguments{}).(arguments)
 	groups := defval(options[FuncGroup], groups{}).(groups)
 	examples := defval(options[FuncExamples], examples{}).(examples)
+	namespace := defval(options[FuncNamespace], "").(string)
 	for key, val := range funcs {
 		funcInfo := &FuncInfo{
 			function:    val,
@@ -218,6 +235,7 @@ func (t *Template) AddFunctions(funcs dictionary, group string, options FuncOpti
 			arguments:   arguments[key],
 			description: help[key],
 			examples:    examples[key],
+			namespace:   namespace,
 		}
 		ft[key] = funcInfo
 	}
@@ -232,15 +250,31 @@ func (t *Template) addFunctions(funcMap funcTableMap) *Template {
 	if t.functions == nil {
 		t.functions = make(funcTableMap)
 	}
+	if t.namespaces == nil {
+		t.namespaces = make(map[string]funcTableMap)
+	}
+	added := make(funcTableMap)
+	register := func(name string, value *FuncInfo) {
+		if namespace := value.namespace; namespace != "" {
+			if t.namespaces[namespace] == nil {
+				t.namespaces[namespace] = make(funcTableMap)
+			}
+			t.namespaces[namespace][name] = value
+			added[namespacedName(namespace, name)] = value
+		}
+		if t.takesPrecedence(value, t.functions[name]) {
+			t.functions[name] = value
+			added[name] = value
+		}
+	}
 	for key, value := range funcMap {
 		value.name = key
-		t.functions[key] = value
+		register(key, value)
 		for i := range value.aliases {
-			funcMap[value.aliases[i]] = &FuncInfo{alias: value, function: value.function, name: value.aliases[i]}
-			t.functions[value.aliases[i]] = funcMap[value.aliases[i]]
+			register(value.aliases[i], &FuncInfo{alias: value, function: value.function, name: value.aliases[i], namespace: value.namespace, extension: value.extension})
 		}
 	}
-	t.Funcs(funcMap.convert())
+	t.Funcs(added.convert())
 	return t
 }
 
@@ -285,5 +319,10 @@ func (t *Template) getFunctions() []string    { return t.getFunctionsInternal(tr
 
 // List the available functions in the template
 func (t *Template) getFunction(name string) *FuncInfo {
+	if namespace, function := collections.Split2(name, "."); function != "" {
+		templateMutex.Lock()
+		defer templateMutex.Unlock()
+		return t.getNamespacedFunction(namespace, function)
+	}
 	return t.functions[name]
 }
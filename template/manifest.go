@@ -0,0 +1,185 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ManifestFileName is the name of the file listing the files generated in a target folder.
+const ManifestFileName = ".gotemplate-manifest.json"
+
+// ManifestEntry describes a file generated by gotemplate.
+type ManifestEntry struct {
+	File     string `json:"file"`               // The generated file
+	Source   string `json:"source,omitempty"`   // The template that generated the file
+	Original string `json:"original,omitempty"` // The backup of the source file if it has been replaced by its result (.original)
+	Hash     string `json:"hash"`               // The hash of the generated content
+	Stale    bool   `json:"stale,omitempty"`    // Indicates that the source does not generate that file anymore
+}
+
+// Manifest keeps track of the files generated in a target folder.
+// The paths are stored relative to the manifest folder, but they are exposed as absolute paths.
+type Manifest struct {
+	folder    string
+	entries   map[string]ManifestEntry
+	generated map[string]ManifestEntry
+	mutex     sync.Mutex
+}
+
+type manifestContent struct {
+	Files []ManifestEntry `json:"files"`
+}
+
+// LoadManifest loads the manifest of the supplied folder (an empty manifest is returned if there is no manifest yet).
+func LoadManifest(folder string) (*Manifest, error) {
+	folder, err := filepath.Abs(folder)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{
+		folder:    folder,
+		entries:   make(map[string]ManifestEntry),
+		generated: make(map[string]ManifestEntry),
+	}
+	content, err := os.ReadFile(m.Filename())
+	if os.IsNotExist(err) {
+		return m, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var manifest manifestContent
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	for _, entry := range manifest.Files {
+		entry.File, entry.Source, entry.Original = m.abs(entry.File), m.abs(entry.Source), m.abs(entry.Original)
+		m.entries[entry.File] = entry
+	}
+	return m, nil
+}
+
+// Filename returns the path of the manifest file.
+func (m *Manifest) Filename() string { return filepath.Join(m.folder, ManifestFileName) }
+
+// Entries returns the files registered in the manifest (sorted by name).
+func (m *Manifest) Entries() []ManifestEntry {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	result := make([]ManifestEntry, 0, len(m.entries))
+	for _, entry := range m.entries {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].File < result[j].File })
+	return result
+}
+
+// Remove unregisters the file from the manifest.
+func (m *Manifest) Remove(file string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.entries, m.abs(file))
+}
+
+// Register a file generated during the current run.
+func (m *Manifest) add(file, source, original string) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	abs := func(path string) string {
+		// The paths supplied by the template are relative to the current folder
+		if path != "" {
+			path, _ = filepath.Abs(path)
+		}
+		return path
+	}
+	file = abs(file)
+	m.generated[file] = ManifestEntry{File: file, Source: abs(source), Original: abs(original)}
+}
+
+// Save merges the files generated since the last save into the manifest and writes it.
+// The hashes are computed on the actual content of the files (i.e. after post-processing such as terraform fmt).
+// Previously generated files that are not generated anymore by a processed source are marked as stale.
+func (m *Manifest) Save() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	processedSources := make(map[string]bool)
+	for file, entry := range m.generated {
+		var err error
+		if entry.Hash, err = HashFile(file); err != nil {
+			if os.IsNotExist(err) {
+				// The file has been removed after its generation
+				delete(m.generated, file)
+				continue
+			}
+			return err
+		}
+		m.generated[file] = entry
+		processedSources[entry.Source] = true
+	}
+	for file, entry := range m.entries {
+		if _, isGenerated := m.generated[file]; !isGenerated && processedSources[entry.Source] {
+			entry.Stale = true
+			m.entries[file] = entry
+		}
+	}
+	for file, entry := range m.generated {
+		m.entries[file] = entry
+	}
+	m.generated = make(map[string]ManifestEntry)
+
+	if len(m.entries) == 0 {
+		// We do not leave an empty manifest behind
+		if err := os.Remove(m.Filename()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	var manifest manifestContent
+	for _, entry := range m.entries {
+		entry.File, entry.Source, entry.Original = m.rel(entry.File), m.rel(entry.Source), m.rel(entry.Original)
+		manifest.Files = append(manifest.Files, entry)
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].File < manifest.Files[j].File })
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.Filename(), append(content, '\n'), 0644)
+}
+
+func (m *Manifest) abs(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(m.folder, filepath.FromSlash(path))
+}
+
+func (m *Manifest) rel(path string) string {
+	if path == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(m.folder, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// HashFile returns the hash of the file content as recorded in the manifest.
+func HashFile(filename string) (string, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(content)
+	return fmt.Sprintf("sha256:%x", hash), nil
+}
@@ -0,0 +1,52 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest(t *testing.T) {
+	t.Parallel()
+	folder := t.TempDir()
+	source := filepath.Join(folder, "test.txt.gt")
+	assert.NoError(t, os.WriteFile(source, []byte(`@(1+2)@save(joinPath(current(), "saved.txt"), "saved")`), 0644))
+
+	manifest, err := LoadManifest(folder)
+	assert.NoError(t, err)
+	template := MustNewTemplate(folder, nil, "", nil).RecordManifest(manifest)
+	resultFiles, err := template.ProcessTemplates("", "", source)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(folder, "test.generated.txt")}, resultFiles)
+	assert.NoError(t, manifest.Save())
+
+	// The manifest is reloaded with absolute paths
+	manifest, err = LoadManifest(folder)
+	assert.NoError(t, err)
+	savedFile := filepath.Join(folder, "saved.txt")
+	resultHash, _ := HashFile(resultFiles[0])
+	savedHash, _ := HashFile(savedFile)
+	assert.Equal(t, []ManifestEntry{
+		{File: savedFile, Source: source, Hash: savedHash},
+		{File: resultFiles[0], Source: source, Hash: resultHash},
+	}, manifest.Entries())
+
+	// Files that are not generated anymore by a processed source are marked as stale
+	assert.NoError(t, os.WriteFile(source, []byte("@(1+2)"), 0644))
+	template.RecordManifest(manifest).SetOption(Overwrite, true)
+	resultFiles, err = template.ProcessTemplates("", "", source)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(folder, "test.txt")}, resultFiles)
+	assert.NoError(t, manifest.Save())
+
+	var stale []string
+	for _, entry := range manifest.Entries() {
+		if entry.Stale {
+			stale = append(stale, filepath.Base(entry.File))
+		}
+	}
+	assert.Equal(t, []string{"saved.txt", "test.generated.txt"}, stale)
+	assert.Len(t, manifest.Entries(), 3)
+}
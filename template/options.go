@@ -25,6 +25,10 @@ const (
 	RenderingDisabled
 	AcceptNoValue
 	StrictErrorCheck
+	DryRun
+	Sandbox
+	Deterministic
+	PreferBuiltins
 )
 
 // Set options to true
@@ -0,0 +1,364 @@
+package template
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+)
+
+// PluginFunction describes a function provided by a plugin (as returned by the functions method of the plugin).
+//
+// The plugins are executables started once and receiving JSON-RPC 2.0 requests on their standard input (one
+// request per line). They must write the responses on their standard output (one response per line). The
+// supported methods are:
+//
+//	functions   Returns the list of functions provided by the plugin ([]PluginFunction)
+//	call        Calls a function with the parameters {"name": "function", "args": [...]} and returns its result
+type PluginFunction struct {
+	Name        string    `json:"name"`
+	Group       string    `json:"group,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Arguments   []string  `json:"args,omitempty"`
+	Aliases     []string  `json:"aliases,omitempty"`
+	Result      string    `json:"result,omitempty"`
+	Examples    []Example `json:"examples,omitempty"`
+}
+
+const (
+	// Maximum duration given to the plugin to return its list of functions.
+	pluginStartTimeout = 10 * time.Second
+	// Delay given to the plugin to terminate once its input is closed.
+	pluginStopDelay = time.Second
+)
+
+type plugin struct {
+	name      string
+	namespace string
+	command   *exec.Cmd
+	input     io.WriteCloser
+	writing   sync.Mutex
+	lastID    int64
+	pending   sync.Map
+	done      chan struct{}
+	err       error
+	functions []PluginFunction
+}
+
+type pluginRequest struct {
+	Version string      `json:"jsonrpc"`
+	ID      int64       `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type pluginResponse struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+var (
+	plugins      = make(map[string]*plugin)
+	pluginsMutex sync.Mutex
+)
+
+// AddPlugin starts the plugin (if it is not already running) and adds the functions it provides to the template.
+// The plugin functions are not available while the extension files are loaded (see NewTemplateWithPlugins).
+func (t *Template) AddPlugin(command string, args ...string) error {
+	if t.options[Sandbox] {
+		return fmt.Errorf("plugin %s cannot be used in sandbox mode", command)
+	}
+	p, err := getPlugin(append([]string{command}, args...))
+	if err != nil {
+		return err
+	}
+	if t.hasPlugin(p) {
+		return nil
+	}
+	t.plugins = append(t.plugins, p)
+	t.addPluginFuncs(p)
+	return nil
+}
+
+// Starts the plugins (if they are not already running) and registers them in the template (their functions are added
+// with the other functions).
+func (t *Template) startPlugins(commandLines [][]string) error {
+	for _, commandLine := range commandLines {
+		if t.options[Sandbox] {
+			InternalLog.Warningf("Plugin %s ignored in sandbox mode", commandLine[0])
+			continue
+		}
+		p, err := getPlugin(commandLine)
+		if err != nil {
+			return err
+		}
+		if !t.hasPlugin(p) {
+			t.plugins = append(t.plugins, p)
+		}
+	}
+	return nil
+}
+
+func (t *Template) hasPlugin(p *plugin) bool {
+	for _, existing := range t.plugins {
+		if existing == p {
+			return true
+		}
+	}
+	return false
+}
+
+// StopPlugins terminates all the running plugins.
+func StopPlugins() {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	for key, p := range plugins {
+		p.stop()
+		delete(plugins, key)
+	}
+}
+
+// Split the plugin command line into arguments. The arguments are separated by spaces and they could be enclosed in
+// single or double quotes. Outside of single quotes, a backslash escapes the following quote, space or backslash (the
+// other backslashes are kept as is to support the Windows paths).
+func splitCommandLine(line string) (result []string, err error) {
+	var (
+		current strings.Builder
+		quote   rune
+		inWord  bool
+	)
+	chars := []rune(line)
+	for i := 0; i < len(chars); i++ {
+		char := chars[i]
+		switch {
+		case char == '\\' && quote != '\'' && i+1 < len(chars) && strings.ContainsRune(`"' \`, chars[i+1]):
+			i++
+			current.WriteRune(chars[i])
+			inWord = true
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else {
+				current.WriteRune(char)
+			}
+		case char == '"' || char == '\'':
+			quote, inWord = char, true
+		case unicode.IsSpace(char):
+			if inWord {
+				result = append(result, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(char)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %s", line)
+	}
+	if inWord {
+		result = append(result, current.String())
+	}
+	return
+}
+
+// Returns the plugins defined in the supplied command lines (empty lines are ignored).
+func pluginCommandLines(lines ...string) (result [][]string, err error) {
+	for _, line := range lines {
+		fields, err := splitCommandLine(line)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			result = append(result, fields)
+		}
+	}
+	return
+}
+
+// Returns the running plugin corresponding to the command line or starts it.
+func getPlugin(commandLine []string) (*plugin, error) {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	key := strings.Join(commandLine, "\x00")
+	if p, found := plugins[key]; found {
+		select {
+		case <-p.done:
+			// The plugin has terminated, we start it again
+		default:
+			return p, nil
+		}
+	}
+	p, err := startPlugin(commandLine)
+	if err != nil {
+		return nil, err
+	}
+	plugins[key] = p
+	return p, nil
+}
+
+func startPlugin(commandLine []string) (p *plugin, err error) {
+	name := filepath.Base(commandLine[0])
+	p = &plugin{name: name, namespace: toNamespace(strings.TrimSuffix(name, filepath.Ext(name))), done: make(chan struct{})}
+	p.command = exec.Command(commandLine[0], commandLine[1:]...)
+	p.command.Stderr = os.Stderr
+	if p.input, err = p.command.StdinPipe(); err != nil {
+		return nil, fmt.Errorf("unable to start plugin %s: %w", p.name, err)
+	}
+	output, err := p.command.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("unable to start plugin %s: %w", p.name, err)
+	}
+	if err = p.command.Start(); err != nil {
+		return nil, fmt.Errorf("unable to start plugin %s: %w", p.name, err)
+	}
+	go p.receive(output)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginStartTimeout)
+	defer cancel()
+	if err = p.call(ctx, "functions", nil, &p.functions); err != nil {
+		p.stop()
+		return nil, fmt.Errorf("unable to get the functions of plugin %s: %w", p.name, err)
+	}
+	for _, function := range p.functions {
+		if function.Name == "" {
+			p.stop()
+			return nil, fmt.Errorf("plugin %s returned a function without name", p.name)
+		}
+	}
+	return p, nil
+}
+
+// Dispatches the responses of the plugin to the pending calls.
+func (p *plugin) receive(output io.Reader) {
+	reader := bufio.NewReader(output)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var response pluginResponse
+			if err := json.Unmarshal(line, &response); err != nil {
+				InternalLog.Errorf("Invalid response from plugin %s: %v", p.name, err)
+			} else if pending, found := p.pending.LoadAndDelete(response.ID); found {
+				pending.(chan pluginResponse) <- response
+			}
+		}
+		if err != nil {
+			p.err = fmt.Errorf("plugin %s has terminated", p.name)
+			close(p.done)
+			p.command.Wait()
+			return
+		}
+	}
+}
+
+func (p *plugin) call(ctx context.Context, method string, params, result interface{}) error {
+	id := atomic.AddInt64(&p.lastID, 1)
+	request, err := json.Marshal(pluginRequest{"2.0", id, method, params})
+	if err != nil {
+		return err
+	}
+	answer := make(chan pluginResponse, 1)
+	p.pending.Store(id, answer)
+	defer p.pending.Delete(id)
+
+	p.writing.Lock()
+	_, err = p.input.Write(append(request, '\n'))
+	p.writing.Unlock()
+	if err != nil {
+		return fmt.Errorf("unable to send request to plugin %s: %w", p.name, err)
+	}
+
+	select {
+	case response := <-answer:
+		if response.Error != nil {
+			return fmt.Errorf("%s (plugin %s error %d)", response.Error.Message, p.name, response.Error.Code)
+		}
+		return json.Unmarshal(response.Result, result)
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		return fmt.Errorf("call to plugin %s interrupted: %w", p.name, ctx.Err())
+	}
+}
+
+func (p *plugin) stop() {
+	p.input.Close()
+	select {
+	case <-p.done:
+	case <-time.After(pluginStopDelay):
+		p.command.Process.Kill()
+	}
+}
+
+// Add the functions provided by the plugins to the template.
+func (t *Template) addPluginFuncs(plugins ...*plugin) {
+	if t.options[Sandbox] {
+		// The plugins functions could have side effects
+		return
+	}
+	functions := make(funcTableMap)
+	for _, p := range plugins {
+		for _, function := range p.functions {
+			p, name := p, function.Name
+			fi := &FuncInfo{
+				group:       defval(function.Group, "Plugin "+p.name).(string),
+				description: function.Description,
+				aliases:     function.Aliases,
+				examples:    function.Examples,
+				in:          strings.Join(function.Arguments, ", "),
+				out:         defval(function.Result, "interface{}").(string),
+				namespace:   p.namespace,
+				extension:   true,
+			}
+			for _, argument := range function.Arguments {
+				// We only keep the arg name and get rid of any supplemental information (likely type)
+				if fields := strings.Fields(argument); len(fields) > 0 {
+					fi.arguments = append(fi.arguments, fields[0])
+				}
+			}
+			fi.function = func(args ...interface{}) (interface{}, error) {
+				return t.callPlugin(p, name, args)
+			}
+			functions[name] = fi
+		}
+	}
+	t.addFunctions(functions)
+}
+
+func (t *Template) callPlugin(p *plugin, name string, args []interface{}) (interface{}, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	var result json.RawMessage
+	if err := p.call(t.getRunContext(), "call", map[string]interface{}{"name": name, "args": args}, &result); err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(result, &value); err != nil {
+		return nil, err
+	}
+	if _, isString := value.(string); isString || value == nil {
+		return value, nil
+	}
+	// The structured results are converted into the dictionaries and lists used by the templates
+	if err := collections.ConvertData(string(result), &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
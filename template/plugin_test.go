@@ -0,0 +1,158 @@
+package template
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const envTestPlugin = "GOTEMPLATE_TEST_PLUGIN"
+
+// TestPluginHelper is not a real test, it implements the plugin started by TestPlugin.
+func TestPluginHelper(t *testing.T) {
+	if os.Getenv(envTestPlugin) == "" {
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var request struct {
+			ID     int64  `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Name string        `json:"name"`
+				Args []interface{} `json:"args"`
+			} `json:"params"`
+		}
+		must(json.Unmarshal(scanner.Bytes(), &request))
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+		switch request.Method {
+		case "functions":
+			response["result"] = []PluginFunction{
+				{Name: "shout", Group: "Text", Description: "Converts to upper case", Arguments: []string{"text string"}, Aliases: []string{"yell"}},
+				{Name: "describe", Arguments: []string{"values"}, Result: "map[string]interface{}"},
+				{Name: "failure"},
+				{Name: "wait"},
+			}
+		case "call":
+			switch request.Params.Name {
+			case "shout":
+				response["result"] = strings.ToUpper(fmt.Sprint(request.Params.Args...))
+			case "describe":
+				response["result"] = map[string]interface{}{"count": len(request.Params.Args), "values": request.Params.Args}
+			case "failure":
+				response["error"] = map[string]interface{}{"code": 1, "message": "failure requested"}
+			case "wait":
+				time.Sleep(10 * time.Second)
+			}
+		}
+		fmt.Println(string(must(json.Marshal(response)).([]byte)))
+	}
+	os.Exit(0)
+}
+
+func TestPlugin(t *testing.T) {
+	t.Setenv(envTestPlugin, "1")
+	t.Cleanup(StopPlugins)
+	command, args := os.Args[0], "-test.run=^TestPluginHelper$"
+
+	template := MustNewTemplate(t.TempDir(), nil, "", nil)
+	assert.NoError(t, template.AddPlugin(command, args))
+	assert.NoError(t, template.AddPlugin(command, args), "Adding the same plugin twice is ignored")
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{"Call", `@shout("hello")`, "HELLO", ""},
+		{"Alias", `@yell("hello")`, "HELLO", ""},
+		{"Structured result", `{{ $result := describe 1 "a" }}{{ $result.count }} {{ index $result.values 1 }}`, "2 a", ""},
+		{"Error", `@failure()`, "", "failure requested (plugin " + filepath.Base(command) + " error 1)"},
+		{"Category", `{{ range categories }}{{ if eq .Name "Text" }}{{ .Functions }}{{ end }}{{ end }}`, "[shout yell]", ""},
+		{"Default group", `{{ (function "describe").Group }} {{ (function "describe").Arguments }}`, "Plugin " + filepath.Base(command) + " values", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := template.ProcessContent(tt.content, "test.gt")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := template.ProcessContentContext(ctx, `@wait()`, "test.gt")
+	assert.ErrorContains(t, err, "call to plugin "+filepath.Base(command)+" interrupted: context deadline exceeded")
+
+	sandboxed := MustNewTemplate(t.TempDir(), nil, "", DefaultOptions().Set(Sandbox))
+	assert.EqualError(t, sandboxed.AddPlugin(command, args), "plugin "+command+" cannot be used in sandbox mode")
+}
+
+func TestPluginFromEnv(t *testing.T) {
+	t.Setenv(envTestPlugin, "1")
+	t.Setenv(EnvPlugins, os.Args[0]+" -test.run=^TestPluginHelper$")
+	t.Cleanup(StopPlugins)
+
+	folder := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "greeting.gte"), []byte(`@define("greeting")@shout("hello") @.name@end`), 0644))
+	result, err := MustNewTemplate(folder, nil, "", nil).ProcessContent(`@include("greeting", dict("name", "world"))`, "test.gt")
+	assert.NoError(t, err)
+	assert.Equal(t, "HELLO world", result)
+
+	t.Setenv(EnvPlugins, filepath.Join(folder, "missing"))
+	_, err = NewTemplate(folder, nil, "", nil)
+	assert.ErrorContains(t, err, "invalid value for GOTEMPLATE_PLUGINS: unable to start plugin missing")
+}
+
+func TestPluginWithSpaces(t *testing.T) {
+	t.Setenv(envTestPlugin, "1")
+	t.Cleanup(StopPlugins)
+
+	// The plugin is located in a folder containing spaces
+	folder := filepath.Join(t.TempDir(), "my plugins")
+	assert.NoError(t, os.Mkdir(folder, 0755))
+	command := filepath.Join(folder, filepath.Base(os.Args[0]))
+	assert.NoError(t, os.Symlink(os.Args[0], command))
+
+	template, err := NewTemplateWithPlugins(t.TempDir(), nil, "", nil, []string{fmt.Sprintf(`%q '-test.run=^TestPluginHelper$'`, command)})
+	assert.NoError(t, err)
+	result, err := template.ProcessContent(`@shout("hello")`, "test.gt")
+	assert.NoError(t, err)
+	assert.Equal(t, "HELLO", result)
+
+	_, err = NewTemplateWithPlugins(t.TempDir(), nil, "", nil, []string{`"` + command})
+	assert.ErrorContains(t, err, "invalid plugin: unterminated quote")
+}
+
+func Test_splitCommandLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"  plugin  --verbose ", []string{"plugin", "--verbose"}},
+		{`"/path to/plugin" 'single "quoted"' "double 'quoted'"`, []string{"/path to/plugin", `single "quoted"`, `double 'quoted'`}},
+		{`/path\ to/plugin \"arg\" ''`, []string{"/path to/plugin", `"arg"`, ""}},
+		{`C:\plugins\plugin.exe 'a\ b'`, []string{`C:\plugins\plugin.exe`, `a\ b`}},
+	}
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.line)
+		assert.NoError(t, err, tt.line)
+		assert.Equal(t, tt.want, got, tt.line)
+	}
+}
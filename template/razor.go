@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/fatih/color"
 	"github.com/sirupsen/logrus"
@@ -14,7 +15,7 @@ func (t *Template) applyRazor(content []byte) (result []byte, changed bool) {
 	if !t.options[Razor] || !t.IsRazor(string(content)) {
 		return content, false
 	}
-	t.ensureInit()
+	replacements := t.ensureInit()
 
 	for _, ignoredExpr := range t.ignoredRazorExpr {
 		ignoredExpr = strings.TrimSpace(ignoredExpr)
@@ -30,7 +31,7 @@ func (t *Template) applyRazor(content []byte) (result []byte, changed bool) {
 		})
 	}
 
-	for _, r := range replacementsInit[fmt.Sprint(t.delimiters)] {
+	for _, r := range replacements {
 		printDebugInfo(r, string(content))
 		if r.parser == nil {
 			content = r.re.ReplaceAll(content, []byte(r.replace))
@@ -50,7 +51,7 @@ var highlight = color.New(color.BgHiBlack, color.FgBlack).SprintFunc()
 // This is indented to simplify the following regular expression for patterns that are repeated several times
 // Warning: The declaration order is important
 var customMetaclass = [][2]string{
-	{"function;", `@reduce;(?P<expr>[id]\([sp][expr]*[sp]\))`},
+	{"function;", `@reduce;(?P<expr>[id](?:\.[id])?\([sp][expr]*[sp]\))`},
 	{"assign;", `(?P<assign>(?:\$[id][ \t,]*){1,2}:=[sp])`},       // Optional assignment
 	{"index;", `(?P<index>\[[expr]+\])`},                          // Extended index operator that support picky selection using ',' as element separator
 	{"selector;", `(?P<selection>\.[expr]+)`},                     // Optional selector following expression indicating that the expression must include the content after the closing ) (i.e. @function(args).selection)
@@ -145,7 +146,10 @@ var expressions = [][]interface{}{
 	{"", fmt.Sprintf(`\x60%s(?P<num>\d+)\x60`, protectString), "", replacementFunc(protectMultiLineStrings)},
 }
 
-var replacementsInit = make(map[string][]replacement)
+var (
+	replacementsInit  = make(map[string][]replacement)
+	replacementsMutex sync.Mutex
+)
 
 type replacementFunc func(replacement, string) string
 type replacement struct {
@@ -157,7 +161,11 @@ type replacement struct {
 	delimiters []string
 }
 
-func (t *Template) ensureInit() {
+// Returns the razor replacements for the template delimiters (the replacements are built on the first call since
+// templates could be processed concurrently).
+func (t *Template) ensureInit() []replacement {
+	replacementsMutex.Lock()
+	defer replacementsMutex.Unlock()
 	delimiters := fmt.Sprint(t.delimiters)
 	if _, ok := replacementsInit[delimiters]; !ok {
 		// We must ensure that search and replacement expression are compatible with the set of delimiters
@@ -202,6 +210,7 @@ func (t *Template) ensureInit() {
 		}
 		replacementsInit[delimiters] = replacements
 	}
+	return replacementsInit[delimiters]
 }
 
 func printDebugInfo(r replacement, content string) {
@@ -0,0 +1,114 @@
+package template
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/coveooss/gotemplate/v3/utils"
+)
+
+// Functions (and their aliases) that have side effects outside of the rendered template (commands, network, files
+// written or termination of the process) and that are disabled in sandbox mode.
+var sandboxDisabledFuncs = []string{"exec", "execWith", "run", "runWith", "exit", "httpGet", "httpDoc", "save", "fatal", "panic"}
+
+// SandboxRoots set the folders from which the templates are allowed to read files in sandbox mode (default to the
+// template folder).
+func (t *Template) SandboxRoots(folders ...string) *Template {
+	t.sandboxRoots = make([]string, 0, len(folders))
+	for _, folder := range folders {
+		if folder = sandboxPath("", folder); !collections.AsList(t.sandboxRoots).Contains(folder) {
+			t.sandboxRoots = append(t.sandboxRoots, folder)
+		}
+	}
+	return t
+}
+
+// Returns the absolute path of the file (relative to folder) with the symbolic links resolved (if the file exists).
+func sandboxPath(folder, file string) string {
+	if !filepath.IsAbs(file) {
+		if folder == "" {
+			folder = utils.Pwd()
+		}
+		file = filepath.Join(folder, file)
+	}
+	if resolved, err := filepath.EvalSymlinks(file); err == nil {
+		return resolved
+	}
+	return filepath.Clean(file)
+}
+
+// Check that the file (relative to folder) is located in one of the sandbox roots.
+func (t *Template) checkSandboxPath(folder, file string) error {
+	if !t.options[Sandbox] {
+		return nil
+	}
+	var path string
+	if t.fsys != nil {
+		// The file system templates only access the files of their file system (expressed from the virtual root)
+		if !filepath.IsAbs(file) && folder != "" {
+			file = filepath.Join(folder, file)
+		}
+		path = t.fsAbs(t.fsName(file))
+	} else {
+		path = sandboxPath(folder, file)
+	}
+	for _, root := range t.sandboxRoots {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("access to %s is not allowed in sandbox mode (allowed folders: %s)", file, strings.Join(t.sandboxRoots, ", "))
+}
+
+func sandboxError(function string) error {
+	return fmt.Errorf("%s is not allowed in sandbox mode", function)
+}
+
+// Replace the functions that have side effects by functions returning an error and confine the functions
+// reading files to the sandbox roots.
+func (t *Template) addSandboxFuncs() {
+	replace := func(name string, function interface{}) {
+		original := t.functions[name]
+		if original == nil || original.alias != nil {
+			// The function is not available (addon disabled)
+			return
+		}
+		replacement := *original
+		// We keep the original signature for the documentation
+		replacement.in, replacement.out = striptColor(original.Arguments()), original.Result()
+		replacement.function = function
+		t.addFunctions(funcTableMap{name: &replacement})
+	}
+
+	for _, name := range sandboxDisabledFuncs {
+		name := name
+		replace(name, func(args ...interface{}) (interface{}, error) { return nil, sandboxError(name) })
+	}
+
+	replace("load", func(filename string, binary ...bool) (interface{}, error) {
+		if err := t.checkSandboxPath("", filename); err != nil {
+			return nil, err
+		}
+		return t.loadFromFile(filename, binary...)
+	})
+	replace("glob", func(args ...interface{}) (collections.IGenericList, error) {
+		files := t.glob(args...)
+		for _, file := range files.Strings() {
+			if err := t.checkSandboxPath("", file); err != nil {
+				return nil, err
+			}
+		}
+		return files, nil
+	})
+}
+
+// Check if the file exists before confining it to the sandbox (include could also be called with a template name
+// or an inline template).
+func (t *Template) checkSandboxInclude(file string) error {
+	if _, err := t.statFile(file); err != nil {
+		return nil
+	}
+	return t.checkSandboxPath(t.folder, file)
+}
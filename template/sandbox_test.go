@@ -0,0 +1,62 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandbox(t *testing.T) {
+	folder, outside := t.TempDir(), t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "data.txt"), []byte("inside"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644))
+	secret := filepath.Join(outside, "secret.txt")
+
+	template := MustNewTemplate(folder, nil, "", DefaultOptions().Set(Sandbox)).SandboxRoots(folder, folder)
+	assert.Equal(t, []string{sandboxPath("", folder)}, template.sandboxRoots, "Duplicated roots are ignored")
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{"Exec", `@exec("echo hello")`, "", "exec is not allowed in sandbox mode"},
+		{"Run alias", `@execute("echo hello")`, "", "exec is not allowed in sandbox mode"},
+		{"Save", `@save("out.txt", "hello")`, "", "save is not allowed in sandbox mode"},
+		{"Exit", `@exit(1)`, "", "exit is not allowed in sandbox mode"},
+		{"Http", `@httpGet("http://localhost")`, "", "httpGet is not allowed in sandbox mode"},
+		{"Alias with exec", `@alias("hello", "exec", "echo hello")`, "", "alias hello using exec is not allowed in sandbox mode"},
+		{"Func with run", `@func("hello", "run", "echo hello", dict())`, "", "alias hello using run is not allowed in sandbox mode"},
+		{"Include inside", `@include("data.txt")`, "inside", ""},
+		{"Include outside", fmt.Sprintf(`@include(%q)`, secret), "", "access to " + secret + " is not allowed in sandbox mode"},
+		{"Include template", `@define("greeting")Hello@end@include("greeting")`, "Hello", ""},
+		{"Load inside", fmt.Sprintf(`@load(%q)`, filepath.Join(folder, "data.txt")), "inside", ""},
+		{"Load outside", fmt.Sprintf(`@load(%q)`, secret), "", "access to " + secret + " is not allowed in sandbox mode"},
+		{"Glob outside", fmt.Sprintf(`@glob(%q)`, filepath.Join(outside, "*")), "", "access to " + secret + " is not allowed in sandbox mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := template.GetNewContext(folder, false).ProcessContent(tt.content, filepath.Join(folder, "test.gt"))
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := os.Stat(filepath.Join(folder, "out.txt"))
+	assert.True(t, os.IsNotExist(err), "No file has been written")
+
+	unrestricted := MustNewTemplate(folder, nil, "", nil)
+	got, err := unrestricted.ProcessContent(fmt.Sprintf(`@load(%q)`, secret), "test.gt")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", got)
+}
@@ -1,13 +1,17 @@
 package template
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 	"unicode"
 
 	"github.com/coveooss/gotemplate/v3/collections"
@@ -18,7 +22,7 @@ import (
 // String is an alias to collections.String
 type String = collections.String
 
-var templateMutex sync.Mutex
+var templateMutex, childrenMutex sync.Mutex
 
 // Template let us extend the functionalities of base go template library.
 type Template struct {
@@ -33,9 +37,24 @@ type Template struct {
 	children         map[string]*Template
 	aliases          funcTableMap
 	functions        funcTableMap
+	namespaces       map[string]funcTableMap
 	options          OptionsSet
 	optionsEnabled   OptionsSet
 	ignoredRazorExpr []string
+	jobs             int
+	output           io.Writer
+	manifest         *Manifest
+	currentFile      string
+	warnings         *warningList
+	sandboxRoots     []string
+	determinism      *determinism
+	fsys             fs.FS
+	fsRoot           string
+	sink             Sink
+	unsaved          *MemorySink
+	runContext       context.Context
+	templateTimeout  time.Duration
+	plugins          []*plugin
 }
 
 // Environment variables that could be defined to override default behaviors.
@@ -50,6 +69,9 @@ const (
 	EnvExtensionPath    = "GOTEMPLATE_PATH"
 	EnvInternalLogLevel = "GOTEMPLATE_INTERNAL_LOG_LEVEL"
 	EnvLogLevel         = "GOTEMPLATE_TEMPLATE_LOG_LEVEL"
+	EnvSeed             = "GOTEMPLATE_SEED"
+	EnvNow              = "GOTEMPLATE_NOW"
+	EnvPlugins          = "GOTEMPLATE_PLUGINS"
 )
 
 const (
@@ -106,9 +128,23 @@ func IsCode(code string) bool {
 // It also processes environment variables for substitutes (GOTEMPLATE_SUBSTITUTES) and ignore razor expressions (GOTEMPLATE_IGNORE_RAZOR).
 // GOTEMPLATE_IGNORE_RAZOR can be a json, yaml or hcl array of strings or a string with comma, newline or space separated values.
 // GOTEMPLATE_SUBSTITUTES must be a list of substitute pattern separated by newlines.
+// GOTEMPLATE_PLUGINS must be a list of plugin command lines separated by newlines (see NewTemplateWithPlugins).
 //
 // Custom delimiters can be specified, with a maximum of three comma-separated parts.
 func NewTemplate(folder string, context interface{}, delimiters string, options OptionsSet, substitutes ...string) (result *Template, err error) {
+	return newTemplate(nil, folder, context, delimiters, options, nil, substitutes...)
+}
+
+// NewTemplateWithPlugins creates a new Template instance (see NewTemplate) using the functions provided by the
+// supplied plugins (see PluginFunction). The plugins are started before loading the extensions, so the extensions
+// can use their functions.
+//
+// Each plugin is a command line, the arguments containing spaces must be quoted (i.e. "/path to/plugin" --verbose).
+func NewTemplateWithPlugins(folder string, context interface{}, delimiters string, options OptionsSet, plugins []string, substitutes ...string) (result *Template, err error) {
+	return newTemplate(nil, folder, context, delimiters, options, plugins, substitutes...)
+}
+
+func newTemplate(fsys fs.FS, folder string, context interface{}, delimiters string, options OptionsSet, plugins []string, substitutes ...string) (result *Template, err error) {
 	defer func() {
 		if rec := recover(); rec != nil {
 			result, err = nil, fmt.Errorf("%v", rec)
@@ -125,8 +161,21 @@ func NewTemplate(folder string, context interface{}, delimiters string, options
 	}
 	t.optionsEnabled = make(OptionsSet)
 	t.folder, _ = filepath.Abs(iif(folder != "", folder, utils.Pwd()).(string))
+	t.fsys, t.fsRoot = fsys, t.folder
 	t.context = iif(context != nil, context, collections.CreateDictionary())
 	t.aliases = make(funcTableMap)
+	t.warnings = new(warningList)
+	t.unsaved = NewMemorySink()
+	t.SandboxRoots(t.folder)
+	seed, now, deterministic, err := deterministicFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	t.determinism = new(determinism)
+	t.determinism.reset(seed, now)
+	if deterministic {
+		t.options[Deterministic] = true
+	}
 	t.delimiters = []string{"{{", "}}", "@"}
 
 	// Set the regular expression replacements
@@ -157,6 +206,22 @@ func NewTemplate(folder string, context interface{}, delimiters string, options
 		t.AppendIgnoreRazorExpression(list...)
 	}
 
+	// The plugins are started before loading the extensions to allow the extensions to use their functions
+	fromEnv, err := pluginCommandLines(strings.Split(os.Getenv(EnvPlugins), "\n")...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %w", EnvPlugins, err)
+	}
+	if err := t.startPlugins(fromEnv); err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %w", EnvPlugins, err)
+	}
+	commandLines, err := pluginCommandLines(plugins...)
+	if err == nil {
+		err = t.startPlugins(commandLines)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid plugin: %w", err)
+	}
+
 	if t.options[Extension] {
 		t.initExtension()
 	}
@@ -182,6 +247,18 @@ func MustNewTemplate(folder string, context interface{}, delimiters string, opti
 	return must(NewTemplate(folder, context, delimiters, options, substitutes...)).(*Template)
 }
 
+// Jobs set the number of templates that can be processed concurrently by ProcessTemplates (default to 1).
+func (t *Template) Jobs(count int) *Template {
+	t.jobs = count
+	return t
+}
+
+// RecordManifest set the manifest used to register the files generated by this template (including the files written by save).
+func (t *Template) RecordManifest(manifest *Manifest) *Template {
+	t.manifest = manifest
+	return t
+}
+
 // TempFolder set temporary folder used by this template.
 func (t *Template) TempFolder(folder string) *Template {
 	t.tempFolder = folder
@@ -191,8 +268,13 @@ func (t *Template) TempFolder(folder string) *Template {
 // GetNewContext returns a distinct context for each folder.
 func (t *Template) GetNewContext(folder string, useCache bool) *Template {
 	folder = iif(folder != "", folder, t.folder).(string)
-	if context, found := t.children[folder]; useCache && found {
-		return context
+	if useCache {
+		// The children contexts may be requested concurrently when templates are processed in parallel
+		childrenMutex.Lock()
+		defer childrenMutex.Unlock()
+		if context, found := t.children[folder]; found {
+			return context
+		}
 	}
 
 	newTemplate := Template(*t)
@@ -218,6 +300,22 @@ func (t *Template) GetNewContext(folder string, useCache bool) *Template {
 	return t.children[folder]
 }
 
+// CloneWithContext returns a new context of the template working on its own copy of the functions, aliases, options
+// and template context where the supplied values have been set. The resulting template could be used concurrently with
+// the original one and the functions or aliases it defines are not visible from the original one.
+func (t *Template) CloneWithContext(values collections.IDictionary) *Template {
+	source := t.fileContext()
+	source.isolate()
+	context := collections.AsDictionary(source.context)
+	if values != nil {
+		for key, value := range values.AsMap() {
+			context.Set(key, value)
+		}
+	}
+	source.context = context
+	return source.GetNewContext("", false)
+}
+
 // IsCode determines if the supplied code appears to have gotemplate code.
 func (t *Template) IsCode(code string) bool {
 	return !strings.Contains(code, noGoTemplate) && (t.IsRazor(code) || strings.Contains(code, t.LeftDelim()) || strings.Contains(code, t.RightDelim()))
@@ -259,24 +357,15 @@ func (t *Template) initExtension() {
 	ext := t.GetNewContext("", false)
 	t.constantKeys = ext.constantKeys
 	ext.options = DefaultOptions()
-
-	var extensionfiles []string
-	if extensionFolders := strings.TrimSpace(os.Getenv(EnvExtensionPath)); extensionFolders != "" {
-		for _, path := range strings.Split(extensionFolders, string(os.PathListSeparator)) {
-			if path != "" {
-				files, _ := utils.FindFilesMaxDepth(path, ExtensionDepth, false, "*.gte")
-				extensionfiles = append(extensionfiles, files...)
-			}
-		}
-	}
-	extensionfiles = append(extensionfiles, utils.MustFindFilesMaxDepth(ext.folder, ExtensionDepth, false, "*.gte")...)
+	ext.options[Sandbox] = t.options[Sandbox]
+	ext.options[Deterministic] = t.options[Deterministic]
 
 	// Retrieve the template extension files
-	for _, file := range extensionfiles {
+	for _, file := range t.ExtensionFiles() {
 		// We just load all the template files available to ensure that all template definition are loaded
 		// We do not use ParseFiles because it names the template with the base name of the file
 		// which result in overriding templates with the same base name in different folders.
-		content := string(must(os.ReadFile(file)).([]byte))
+		content := string(must(t.readFile(file)).([]byte))
 
 		// We execute the content, but we ignore errors. The goal is only to register the sub templates and aliases properly
 		// We also do not ask to clone the context as we wish to let extension to be able to alter the supplied context
@@ -294,6 +383,28 @@ func (t *Template) initExtension() {
 	t.children = make(map[string]*Template)
 }
 
+// ExtensionFiles returns the list of gotemplate extension files (.gte) that are loaded by the template.
+// The files are searched in the folders defined by GOTEMPLATE_PATH and in the template folder.
+func (t *Template) ExtensionFiles() (extensionFiles []string) {
+	if t.fsys != nil {
+		// The GOTEMPLATE_PATH folders are not considered when using a file system
+		files, _ := utils.FindFilesFS(t.fsys, t.fsName(t.folder), ExtensionDepth, "*.gte")
+		for _, file := range files {
+			extensionFiles = append(extensionFiles, t.fsAbs(file))
+		}
+		return
+	}
+	if extensionFolders := strings.TrimSpace(os.Getenv(EnvExtensionPath)); extensionFolders != "" {
+		for _, path := range strings.Split(extensionFolders, string(os.PathListSeparator)) {
+			if path != "" {
+				files, _ := utils.FindFilesMaxDepth(path, ExtensionDepth, false, "*.gte")
+				extensionFiles = append(extensionFiles, files...)
+			}
+		}
+	}
+	return append(extensionFiles, utils.MustFindFilesMaxDepth(t.folder, ExtensionDepth, false, "*.gte")...)
+}
+
 // Initialize a new template with same attributes as the current context.
 func (t *Template) init(folder string) {
 	if folder != "" {
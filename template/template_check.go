@@ -0,0 +1,209 @@
+package template
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"github.com/coveooss/multilogger/errors"
+)
+
+// CheckTemplates applies the razor conversion and parses the supplied templates without executing them.
+// The templates could either be file names or inline code. All parsing errors are returned (not only
+// the first one found in each template).
+func (t *Template) CheckTemplates(templates ...string) error {
+	var errs errors.Array
+	for i := range templates {
+		errs = append(errs, t.checkTemplate(templates[i])...)
+	}
+	return errs.AsError()
+}
+
+// CheckContent applies the razor conversion and parses the supplied content without executing it. The filename is
+// only used to report the location of the errors.
+func (t *Template) CheckContent(content, filename string) error {
+	return t.checkContent(content, filename).AsError()
+}
+
+func (t *Template) checkTemplate(template string) (errs errors.Array) {
+	source, filename := template, "."
+	if content, err := t.readFile(template); err == nil {
+		source, filename = string(content), template
+	} else if !t.IsCode(template) {
+		return errors.Array{err}
+	}
+	return t.checkContent(source, filename)
+}
+
+func (t *Template) checkContent(source, filename string) (errs errors.Array) {
+	code, shebang := t.prepareCode(source)
+	if !t.IsCode(code) {
+		return
+	}
+	InternalLog.Debug("Checking ", filename)
+
+	sourceLines := strings.Split(source, "\n")
+	if shebang {
+		// The shebang line has been removed from the code
+		sourceLines = sourceLines[1:]
+	}
+	codeLines := strings.Split(code, "\n")
+	for range codeLines {
+		var err error
+		context := t.GetNewContext(filepath.Dir(filename), false)
+		newTemplate := context.New(filename)
+		func() {
+			// We cannot call the parser without locking
+			templateMutex.Lock()
+			defer templateMutex.Unlock()
+			_, err = context.parseCode(newTemplate, strings.Join(codeLines, "\n"))
+		}()
+		if err == nil {
+			return
+		}
+
+		matches := reCheckError.FindStringSubmatch(err.Error())
+		if matches == nil {
+			return append(errs, err)
+		}
+		line, message := toInt(matches[1]), matches[4]
+		column, _ := strconv.Atoi(matches[3])
+		if actual := reUnclosedAction.FindStringSubmatch(message); actual != nil {
+			// The actual error occurred in another line
+			line = toInt(actual[1])
+		}
+		if line < 1 || line > len(codeLines) || codeLines[line-1] == "" {
+			// We are unable to remove the faulty line, so we cannot continue to search for further errors
+			return append(errs, err)
+		}
+
+		var sourceLine string
+		if line <= len(sourceLines) {
+			sourceLine = sourceLines[line-1]
+		}
+		if token := reQuotedToken.FindStringSubmatch(message); token != nil {
+			// The error refers to a specific token, we try to locate it in the original source
+			if pos := strings.Index(sourceLine, token[1]); pos >= 0 {
+				column = pos + 1
+			}
+		}
+
+		errs = append(errs, newTemplateError(nil, filename, line, column, strings.TrimSpace(sourceLine), message))
+
+		// We blank the faulty line and try again to find further errors
+		codeLines[line-1] = ""
+	}
+	return
+}
+
+var (
+	reCheckError     = regexp.MustCompile(`(?s)^template: .*?:(\d+)(:(\d+))?: (.*)$`)
+	reUnclosedAction = regexp.MustCompile(`unclosed action started at .*:(\d+)`)
+	reQuotedToken    = regexp.MustCompile(`"([^"]+)"`)
+)
+
+// ParseExtensions registers the sub-templates defined in the extension files (.gte) by parsing them without executing
+// them. The aliases and functions defined by the extensions with a literal name are declared so the templates that
+// use them can be checked, but calling them returns an error. The template must be created without the Extension
+// option to avoid executing the extensions.
+func (t *Template) ParseExtensions() {
+	for _, file := range t.ExtensionFiles() {
+		if err := t.parseExtension(file); err != nil {
+			InternalLog.Error(err)
+		}
+	}
+}
+
+func (t *Template) parseExtension(file string) error {
+	content, err := t.readFile(file)
+	if err != nil {
+		return err
+	}
+	code, _ := t.prepareCode(string(content))
+
+	err = func() error {
+		// We cannot call the parser without locking
+		templateMutex.Lock()
+		defer templateMutex.Unlock()
+
+		trees := make(map[string]*parse.Tree)
+		tree := parse.New(file)
+		tree.Mode = parse.SkipFuncCheck
+		if _, err := tree.Parse(code, t.LeftDelim(), t.RightDelim(), trees); err != nil {
+			return err
+		}
+		for name, tree := range trees {
+			t.declareExtensionFuncs(file, tree.Root)
+			if name != file {
+				if _, err := t.AddParseTree(name, tree); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}()
+	t.addFunctions(t.aliases)
+	return err
+}
+
+// Walk the parse tree to declare the aliases and functions defined by the extension file.
+func (t *Template) declareExtensionFuncs(file string, node parse.Node) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node != nil {
+			for _, child := range node.Nodes {
+				t.declareExtensionFuncs(file, child)
+			}
+		}
+	case *parse.ActionNode:
+		t.declareExtensionFuncs(file, node.Pipe)
+	case *parse.IfNode:
+		t.declareExtensionFuncs(file, node.Pipe)
+		t.declareExtensionFuncs(file, node.List)
+		t.declareExtensionFuncs(file, node.ElseList)
+	case *parse.RangeNode:
+		t.declareExtensionFuncs(file, node.Pipe)
+		t.declareExtensionFuncs(file, node.List)
+		t.declareExtensionFuncs(file, node.ElseList)
+	case *parse.WithNode:
+		t.declareExtensionFuncs(file, node.Pipe)
+		t.declareExtensionFuncs(file, node.List)
+		t.declareExtensionFuncs(file, node.ElseList)
+	case *parse.TemplateNode:
+		t.declareExtensionFuncs(file, node.Pipe)
+	case *parse.PipeNode:
+		if node != nil {
+			for _, command := range node.Cmds {
+				t.declareExtensionFuncs(file, command)
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			t.declareExtensionFuncs(file, arg)
+		}
+		function, isIdentifier := node.Args[0].(*parse.IdentifierNode)
+		if !isIdentifier || len(node.Args) < 2 || t.functions[function.Ident] == nil {
+			return
+		}
+		name, isString := node.Args[1].(*parse.StringNode)
+		if !isString {
+			return
+		}
+		switch t.functions[function.Ident].RealName() {
+		case "alias", "aliasWith", "localAlias", "localAliasWith", "func":
+			if t.aliases[name.Text] == nil {
+				t.aliases[name.Text] = &FuncInfo{
+					function: func(args ...interface{}) (interface{}, error) {
+						return nil, fmt.Errorf("%s is defined in %s that has not been executed", name.Text, file)
+					},
+					group:     "User defined aliases",
+					namespace: extensionNamespace,
+					extension: true,
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTemplates(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		content string
+		errors  []string
+	}{
+		{"No templating", "Hello", nil},
+		{"Valid razor", "@{value} := 3\n@(value + 2)", nil},
+		{"Valid go template", "{{ add 1 2 }}", nil},
+		{"Not executed", `@exec("exit 1")`, nil},
+		{"Undefined function", "Line 1\n@undefined_func(2)", []string{`test.gt:2:2: function "undefined_func" not defined`}},
+		{"Undefined variable", "{{ $value }}", []string{`test.gt:1:4: undefined variable "$value"`}},
+		{
+			"Multiple errors", "{{ $value }}\nValid\n{{ $other }}",
+			[]string{`test.gt:1:4: undefined variable "$value"`, `test.gt:3:4: undefined variable "$other"`},
+		},
+		{"Unclosed action", "Line 1\n{{ add 1\n2\n", []string{"test.gt:2: unclosed action"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			folder := t.TempDir()
+			file := filepath.Join(folder, "test.gt")
+			assert.NoError(t, os.WriteFile(file, []byte(tt.content), 0644))
+
+			template := MustNewTemplate(folder, nil, "", nil)
+			err := template.CheckTemplates(file)
+			if tt.errors == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			message := striptColor(err.Error())
+			for _, expected := range tt.errors {
+				assert.Contains(t, message, expected)
+			}
+		})
+	}
+}
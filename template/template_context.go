@@ -0,0 +1,88 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+)
+
+// ProcessContentContext loads and runs the file template, the processing is interrupted (including the commands
+// and the http requests made by the template) when the context is done.
+func (t *Template) ProcessContentContext(ctx context.Context, content, source string) (string, error) {
+	return t.withContext(ctx).ProcessContent(content, source)
+}
+
+// ProcessTemplatesContext loads and runs the file template or execute the content if it is not a file, the
+// processing is interrupted (including the commands and the http requests made by the templates) when the
+// context is done.
+func (t *Template) ProcessTemplatesContext(ctx context.Context, sourceFolder, targetFolder string, templates ...string) (resultFiles []string, err error) {
+	return t.withContext(ctx).ProcessTemplates(sourceFolder, targetFolder, templates...)
+}
+
+// TemplateTimeout set the maximum duration of the processing of each template by ProcessTemplates (0 = no limit).
+func (t *Template) TemplateTimeout(timeout time.Duration) *Template {
+	t.templateTimeout = timeout
+	return t
+}
+
+// Delay given to the running function to return its own error once the template processing is interrupted.
+const interruptionDelay = 2 * time.Second
+
+// Returns a copy of the template running in the supplied context.
+func (t *Template) withContext(ctx context.Context) *Template {
+	result := t.fileContext()
+	result.runContext = ctx
+	// The functions are bound to the contexts that registered them, so the folder contexts cannot be reused
+	result.children = make(map[string]*Template)
+	return result
+}
+
+// Returns the context in which the template is running.
+func (t *Template) getRunContext() context.Context {
+	if t.runContext == nil {
+		return context.Background()
+	}
+	return t.runContext
+}
+
+// Returns a descriptive error if the running context has been cancelled or has expired.
+func (t *Template) interrupted(action string) error {
+	if err := t.getRunContext().Err(); err != nil {
+		return fmt.Errorf("%s interrupted: %w", action, err)
+	}
+	return nil
+}
+
+// Execute the template, it stops waiting for the result if the running context is done.
+func (t *Template) execute(template *template.Template, out io.Writer, data interface{}) error {
+	done := t.getRunContext().Done()
+	if done == nil {
+		return template.Execute(out, data)
+	}
+	if err := t.interrupted(fmt.Sprintf("template %s", template.Name())); err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	result := make(chan error, 1)
+	go func() { result <- template.Execute(&buffer, data) }()
+	select {
+	case err := <-result:
+		out.Write(buffer.Bytes())
+		return err
+	case <-done:
+	}
+
+	// The running commands and requests are also interrupted, so we give them a chance to report the faulty call
+	select {
+	case err := <-result:
+		if err != nil {
+			return err
+		}
+	case <-time.After(interruptionDelay):
+	}
+	return t.interrupted(fmt.Sprintf("template %s", template.Name()))
+}
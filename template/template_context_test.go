@@ -0,0 +1,82 @@
+package template
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessContentContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-r.Context().Done() }))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr []string
+	}{
+		{"Exec", `@exec("sleep 10")`, []string{"error calling exec", `command "sleep 10" interrupted: context deadline exceeded`}},
+		{"Run", `@run("sleep 10")`, []string{"error calling run", `command "sleep 10" interrupted: context deadline exceeded`}},
+		{"Http", `@httpGet("` + server.URL + `")`, []string{"error calling httpGet", "request to " + server.URL + " interrupted: context deadline exceeded"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := MustNewTemplate(t.TempDir(), nil, "", nil)
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			_, err := template.ProcessContentContext(ctx, tt.content, "test.gt")
+			assert.Less(t, time.Since(start), 5*time.Second)
+			if assert.Error(t, err) {
+				for _, want := range tt.wantErr {
+					assert.Contains(t, err.Error(), want)
+				}
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := MustNewTemplate(t.TempDir(), nil, "", nil).ProcessContentContext(ctx, "@(1+1)", "test.gt")
+	assert.EqualError(t, err, "template test.gt interrupted: context canceled")
+}
+
+func TestTemplateTimeout(t *testing.T) {
+	folder := t.TempDir()
+	slow, fast := filepath.Join(folder, "slow.txt.gt"), filepath.Join(folder, "fast.txt.gt")
+	assert.NoError(t, os.WriteFile(slow, []byte(`@run("sleep 10")`), 0644))
+	assert.NoError(t, os.WriteFile(fast, []byte(`@(1+1)`), 0644))
+
+	template := MustNewTemplate(folder, nil, "", DefaultOptions().Set(Overwrite)).TemplateTimeout(200 * time.Millisecond)
+	files, err := template.ProcessTemplates(folder, folder, slow, fast)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "slow.txt.gt")
+		assert.Contains(t, err.Error(), `command "sleep 10" interrupted`)
+	}
+	assert.Equal(t, []string{filepath.Join(folder, "fast.txt")}, files)
+}
+
+func TestAbandonedTemplateHasNoSideEffect(t *testing.T) {
+	folder := t.TempDir()
+	late := filepath.Join(folder, "late.txt")
+	release := make(chan struct{})
+	template := MustNewTemplate(folder, nil, "", nil)
+	template.AddFunctions(map[string]interface{}{"waitRelease": func() string { <-release; return "" }}, "Test", nil)
+
+	// The blocking function cannot be interrupted, so the template is abandoned while it is still running
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := template.ProcessContentContext(ctx, `@waitRelease()@save("`+late+`", "late")`, "test.gt")
+	assert.EqualError(t, err, "template test.gt interrupted: context deadline exceeded")
+
+	// Once released, the template continues, but the file must not be written
+	close(release)
+	time.Sleep(200 * time.Millisecond)
+	assert.NoFileExists(t, late)
+}
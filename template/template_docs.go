@@ -0,0 +1,85 @@
+package template
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+)
+
+// Documentation contains the reference of the functions (including the ones defined by the extensions) and the
+// methods of the objects available in the templates.
+type Documentation struct {
+	Categories []CategoryDoc `json:"categories"`
+	Objects    []ObjectDoc   `json:"objects"`
+}
+
+// CategoryDoc documents a group of functions.
+type CategoryDoc struct {
+	Name      string    `json:"name"`
+	URL       string    `json:"url,omitempty"` // The external reference of the group (if any)
+	Functions []FuncDoc `json:"functions"`
+}
+
+// FuncDoc documents a function (the aliases are not documented separately).
+type FuncDoc struct {
+	Name        string    `json:"name"`
+	Signature   string    `json:"signature"`
+	Description string    `json:"description,omitempty"`
+	Aliases     []string  `json:"aliases,omitempty"`
+	Examples    []Example `json:"examples,omitempty"`
+}
+
+// ObjectDoc documents the methods of an object.
+type ObjectDoc struct {
+	Name    string   `json:"name"`
+	Methods []string `json:"methods"`
+}
+
+var reCategoryURL = regexp.MustCompile(`^(?P<name>.*), (?P<url>https?://.*)$`)
+
+// Documentation returns the reference of all functions grouped by category and the methods of the String,
+// StringArray, list and dictionary objects. The examples are completed with their go template and result.
+func (t *Template) Documentation() (result Documentation) {
+	t = t.GetNewContext("", false)
+	t.completeExamples()
+
+	for _, category := range t.getCategories() {
+		categoryDoc := CategoryDoc{Name: category.Name()}
+		if matches := reCategoryURL.FindStringSubmatch(categoryDoc.Name); matches != nil {
+			categoryDoc.Name, categoryDoc.URL = matches[1], matches[2]
+		}
+		for _, name := range category.Functions() {
+			function := t.getFunction(name)
+			if function.IsAlias() {
+				continue
+			}
+			categoryDoc.Functions = append(categoryDoc.Functions, FuncDoc{
+				Name:        name,
+				Signature:   striptColor(function.Signature()),
+				Description: function.Description(),
+				Aliases:     function.Aliases(),
+				Examples:    function.Examples(),
+			})
+		}
+		if len(categoryDoc.Functions) > 0 {
+			result.Categories = append(result.Categories, categoryDoc)
+		}
+	}
+
+	for _, object := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"String", String("")},
+		{"StringArray", String("").Split("")},
+		{"List", collections.CreateList()},
+		{"Dictionary", collections.CreateDictionary()},
+	} {
+		result.Objects = append(result.Objects, ObjectDoc{
+			Name:    object.name,
+			Methods: strings.Split(striptColor(getMethods(object.value)), "\n"),
+		})
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentation(t *testing.T) {
+	folder := t.TempDir()
+	extension := `@define("greet")Hello @.name@end
+@func("greet", "template", "greet", dict("args", list("name"), "description", "Say hello"))`
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "ext.gte"), []byte(extension), 0644))
+
+	doc := MustNewTemplate(folder, nil, "", nil).Documentation()
+	categories := make(map[string]CategoryDoc)
+	for _, category := range doc.Categories {
+		categories[category.Name] = category
+	}
+
+	sprigDate := categories["Sprig Date"]
+	assert.Equal(t, "http://masterminds.github.io/sprig/date.html", sprigDate.URL)
+	for _, function := range sprigDate.Functions {
+		assert.NotEqual(t, "date_in_zone", function.Name, "Aliases are documented with their function")
+		if function.Name == "dateInZone" {
+			assert.Equal(t, "dateInZone(fmt string, date interface{}, zone string) string", function.Signature)
+			assert.Equal(t, []string{"date_in_zone"}, function.Aliases)
+		}
+	}
+
+	for _, function := range categories["Data Manipulation"].Functions {
+		if function.Name == "hasKey" {
+			assert.Equal(t, Example{
+				Razor:    `@hasKey(dict("key", "value"), "key")`,
+				Template: `{{ hasKey (dict "key" "value") "key" }}`,
+				Result:   "true",
+			}, function.Examples[0], "Examples are completed")
+		}
+	}
+
+	userDefined := categories["User defined functions"].Functions
+	if assert.Len(t, userDefined, 1, "Functions defined in the extensions are documented") {
+		assert.Equal(t, "greet(name) interface{}", userDefined[0].Signature)
+		assert.Equal(t, "Say hello", userDefined[0].Description)
+	}
+
+	var objects []string
+	for _, object := range doc.Objects {
+		objects = append(objects, object.Name)
+		assert.NotEmpty(t, object.Methods)
+	}
+	assert.Equal(t, []string{"String", "StringArray", "List", "Dictionary"}, objects)
+	assert.Contains(t, doc.Objects[0].Methods, "Center(int) String")
+}
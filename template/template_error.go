@@ -0,0 +1,123 @@
+package template
+
+import (
+	goerrors "errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/coveooss/multilogger/errors"
+	"github.com/fatih/color"
+)
+
+// Severity indicates the importance of a template diagnostic.
+type Severity string
+
+// Severity values
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// TemplateError describes a problem found in a template at a specific location.
+// It wraps the human readable error reported during the template processing.
+type TemplateError struct {
+	File     string   `json:"file"`              // The template file (or . if the template has been supplied as code)
+	Line     int      `json:"line,omitempty"`    // The line of the error (0 if the error is not related to a specific line)
+	Column   int      `json:"column,omitempty"`  // The column of the error (0 if the column is unknown)
+	Excerpt  string   `json:"excerpt,omitempty"` // The source code where the error occurred
+	Message  string   `json:"message"`           // The error message (without location nor color)
+	Severity Severity `json:"severity"`
+	err      error
+}
+
+func newTemplateError(err error, file string, line, column int, excerpt, message string) *TemplateError {
+	return &TemplateError{
+		File:     file,
+		Line:     line,
+		Column:   column,
+		Excerpt:  excerpt,
+		Message:  message,
+		Severity: SeverityError,
+		err:      err,
+	}
+}
+
+func (e *TemplateError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	location := e.File
+	if e.Line > 0 {
+		location += fmt.Sprintf(":%d", e.Line)
+		if e.Column > 0 {
+			location += fmt.Sprintf(":%d", e.Column)
+		}
+	}
+	result := fmt.Sprintf("%s: %s", color.WhiteString(location), color.RedString(e.Message))
+	if e.Excerpt != "" {
+		result += " in: " + color.HiBlackString(e.Excerpt)
+	}
+	return result
+}
+
+// Unwrap returns the original error.
+func (e *TemplateError) Unwrap() error { return e.err }
+
+// Diagnostics converts the supplied error into a list of template errors. Aggregated errors (errors.Array) are
+// flattened and errors that are not TemplateError are converted using the location found in their message (if any).
+func Diagnostics(err error) (result []*TemplateError) {
+	if err == nil {
+		return nil
+	}
+	if array, isArray := err.(errors.Array); isArray {
+		for i := range array {
+			result = append(result, Diagnostics(array[i])...)
+		}
+		return
+	}
+
+	var templateError *TemplateError
+	if goerrors.As(err, &templateError) {
+		return []*TemplateError{templateError}
+	}
+	if matches := reTemplateError.FindStringSubmatch(err.Error()); matches != nil {
+		line, _ := strconv.Atoi(matches[2])
+		column, _ := strconv.Atoi(matches[3])
+		return []*TemplateError{newTemplateError(err, matches[1], line, column, "", matches[4])}
+	}
+	return []*TemplateError{newTemplateError(err, "", 0, 0, "", err.Error())}
+}
+
+var reTemplateError = regexp.MustCompile(`(?s)^template: (.*?):(\d*)(?::(\d+))?: (.*)$`)
+
+// The warnings are shared between a template and all its derived contexts.
+type warningList struct {
+	sync.Mutex
+	warnings []*TemplateError
+}
+
+func (w *warningList) add(err error) {
+	if w == nil {
+		return
+	}
+	w.Lock()
+	defer w.Unlock()
+	for _, diagnostic := range Diagnostics(err) {
+		warning := *diagnostic
+		warning.Severity = SeverityWarning
+		w.warnings = append(w.warnings, &warning)
+	}
+}
+
+// Warnings returns the warnings (i.e. ignored errors) found since the last call.
+func (t *Template) Warnings() (warnings []*TemplateError) {
+	if t.warnings == nil {
+		return
+	}
+	t.warnings.Lock()
+	defer t.warnings.Unlock()
+	warnings, t.warnings.warnings = t.warnings.warnings, nil
+	return
+}
@@ -2,10 +2,10 @@ package template
 
 import (
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
 
+	"github.com/coveooss/multilogger/errors"
 	"github.com/coveooss/multilogger/reutils"
 	"github.com/fatih/color"
 )
@@ -43,25 +43,27 @@ func (t errorHandler) Handler(err error) (string, bool, error) {
 			// The actual error occurred in another line
 			faultyLine = toInt(actualLine) - 1
 		}
-		faultyColumn := 0
+		faultyColumn, reportedColumn := 0, 0
 		key, message, errText, code, value := matches[tagKey], matches[tagMsg], matches[tagErr], matches[tagCode], matches[tagValue]
 
 		if matches[tagCol] != "" {
-			faultyColumn = toInt(matches[tagCol]) - 1
+			reportedColumn = toInt(matches[tagCol])
+			faultyColumn = reportedColumn - 1
 		}
 
-		errorText := color.RedString(errText)
+		errorText, diagnosticMessage := color.RedString(errText), errText
 
 		if matches[tagFile] != t.Filename {
 			// An error occurred in an included external template file, we cannot try to recuperate
 			// and try to find further errors, so we just return the error.
 			var line string
-			if fileContent, err := os.ReadFile(matches[tagFile]); err != nil {
+			if fileContent, err := t.readFile(matches[tagFile]); err != nil {
 				line = fmt.Sprintf("Unable to read file: %v", err)
 			} else {
 				line = String(fileContent).Lines()[toInt(matches[tagLine])-1].Str()
 			}
-			return "", true, fmt.Errorf("%s %w in: %s", color.WhiteString(t.Filename), err, color.HiBlackString(line))
+			err = fmt.Errorf("%s %w in: %s", color.WhiteString(t.Filename), err, color.HiBlackString(line))
+			return "", true, newTemplateError(err, matches[tagFile], toInt(matches[tagLine]), reportedColumn, strings.TrimSpace(line), errText)
 		}
 
 		currentLine := String(lines[faultyLine])
@@ -122,17 +124,18 @@ func (t errorHandler) Handler(err error) (string, bool, error) {
 			} else {
 				logMessage = fmt.Sprintf("Unmanaged undefined value %s: %s", key, context)
 				errorText = color.RedString("Undefined value ") + color.YellowString(key)
+				diagnosticMessage = "Undefined value " + key
 				lines[faultyLine] = newLine.Str()
 			}
 		} else if message != "" {
 			logMessage = fmt.Sprintf("User defined error: %s", message)
-			errorText = color.RedString(message)
+			errorText, diagnosticMessage = color.RedString(message), message
 			lines[faultyLine] = fmt.Sprintf("ERROR %s", errText)
 		} else if code != "" {
 			context := String(currentLine).SelectContext(faultyColumn, t.LeftDelim(), t.RightDelim())
 			logMessage = fmt.Sprintf("Execution error: %s Code = %s Context = %s", err, color.HiBlackString(code), color.HiBlackString(context.Str()))
 
-			errorText = color.RedString("%s (%s)", errText, code)
+			errorText, diagnosticMessage = color.RedString("%s (%s)", errText, code), fmt.Sprintf("%s (%s)", errText, code)
 			if context == "" {
 				// We have not been able to find the current context, we wipe the erroneous line
 				lines[faultyLine] = fmt.Sprintf("ERROR %s", errText)
@@ -154,11 +157,16 @@ func (t errorHandler) Handler(err error) (string, bool, error) {
 			InternalLog.Debug(logMessage)
 		}
 
+		var templateError *TemplateError
 		if err != nil {
-			err = fmt.Errorf("%s%s%s", color.WhiteString(matches[tagLocation]), errorText, errorLine)
+			templateError = newTemplateError(fmt.Errorf("%s%s%s", color.WhiteString(matches[tagLocation]), errorText, errorLine),
+				t.Filename, faultyLine+1, reportedColumn, strings.TrimSpace(t.Lines[faultyLine]), diagnosticMessage)
+			err = templateError
 		}
-		if lines[faultyLine] != currentLine.Str() || strings.Contains(err.Error(), noValueError) {
+		interrupted := t.getRunContext().Err() != nil
+		if (lines[faultyLine] != currentLine.Str() || strings.Contains(err.Error(), noValueError)) && !interrupted {
 			// If we changed something in the current text, we try to continue the evaluation to get further errors
+			// (unless the processing has been interrupted)
 			newCode := strings.Join(lines, "\n")
 			if err != nil {
 				InternalLog.Infof("Retrying %d with:\n%s", t.Try, color.HiBlackString(String(newCode).AddLineNumber(0).Str()))
@@ -168,9 +176,10 @@ func (t errorHandler) Handler(err error) (string, bool, error) {
 				if err != nil {
 					if err.Error() == err2.Error() {
 						// TODO See: https://github.com/golang/go/issues/27319
-						err = fmt.Errorf("%v\n%s", err, color.HiRedString("Unable to continue processing to check for further errors"))
+						// The note is attached to the current error to avoid reporting a diagnostic without location
+						templateError.err = fmt.Errorf("%w\n%s", templateError.err, color.HiRedString("Unable to continue processing to check for further errors"))
 					} else {
-						err = fmt.Errorf("%v\n%v", err, err2)
+						err = errors.Array{err, err2}
 					}
 				} else {
 					err = err2
@@ -0,0 +1,93 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/multilogger/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+	template := MustNewTemplate(t.TempDir(), nil, "", nil)
+
+	tests := []struct {
+		name     string
+		code     string
+		expected []TemplateError
+	}{
+		{"No error", "@(1+2)", nil},
+		{
+			"Multiple errors", "@{a} := $undefined\nOk\n@non_existing_func()",
+			[]TemplateError{
+				{File: "test.gt", Line: 1, Excerpt: "@{a} := $undefined", Message: `undefined variable "$undefined"`, Severity: SeverityError},
+				{File: "test.gt", Line: 3, Excerpt: "@non_existing_func()", Message: `function "non_existing_func" not defined`, Severity: SeverityError},
+			},
+		},
+		{
+			"Execution error", "@(1/0)",
+			[]TemplateError{
+				{File: "test.gt", Line: 1, Column: 3, Excerpt: "@(1/0)", Message: "error calling div: division by 0 (div 1 0)", Severity: SeverityError},
+			},
+		},
+		{
+			"Unable to continue", "@for ($i := $value)\n  text\n@end",
+			[]TemplateError{
+				{File: "test.gt", Line: 1, Excerpt: "@for ($i := $value)", Message: `undefined variable "$value"`, Severity: SeverityError},
+				{File: "test.gt", Line: 1, Column: 15, Excerpt: "@for ($i := $value)", Message: `range can't iterate over <UNDEF $value> ("<UNDEF $value>")`, Severity: SeverityError},
+				{File: "test.gt", Line: 3, Excerpt: "@end", Message: "unexpected {{end}}", Severity: SeverityError},
+			},
+		},
+		{
+			"Undefined value", "Value = @value",
+			[]TemplateError{{File: "test.gt", Excerpt: "Value = <no value>", Message: noValueError, Severity: SeverityError}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := template.processContentInternal(tt.code, "test.gt", nil, 0, true, nil)
+			var diagnostics []TemplateError
+			for _, diagnostic := range Diagnostics(err) {
+				diagnostic.err = nil
+				diagnostics = append(diagnostics, *diagnostic)
+			}
+			assert.Equal(t, tt.expected, diagnostics)
+		})
+	}
+}
+
+func TestDiagnosticsFromOtherErrors(t *testing.T) {
+	t.Parallel()
+	err := errors.Array{
+		fmt.Errorf("template: file.txt:12:3: unexpected error"),
+		errors.Array{fmt.Errorf("simple error")},
+	}
+	assert.Equal(t, []*TemplateError{
+		newTemplateError(err[0], "file.txt", 12, 3, "", "unexpected error"),
+		newTemplateError(err[1].(errors.Array)[0], "", 0, 0, "", "simple error"),
+	}, Diagnostics(err))
+}
+
+func TestWarnings(t *testing.T) {
+	t.Parallel()
+	folder := t.TempDir()
+	file := filepath.Join(folder, "test.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("a\n@non_existing_func()"), 0644))
+
+	template := MustNewTemplate(folder, nil, "", nil)
+	template.SetOption(Overwrite, true)
+	_, err := template.ProcessTemplates("", "", file)
+	assert.NoError(t, err, "The error is ignored since the file is not a gotemplate file")
+
+	warnings := template.Warnings()
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, SeverityWarning, warnings[0].Severity)
+		assert.Equal(t, 2, warnings[0].Line)
+		assert.Equal(t, `function "non_existing_func" not defined`, warnings[0].Message)
+	}
+	assert.Empty(t, template.Warnings(), "Warnings are cleared once returned")
+}
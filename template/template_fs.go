@@ -0,0 +1,158 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/coveooss/gotemplate/v3/utils"
+)
+
+// Sink receives the files generated by the templates (the rendered files and the files written by save).
+// The names are slash separated paths relative to the template folder.
+type Sink interface {
+	WriteFile(name string, content []byte, mode fs.FileMode) error
+}
+
+// MemorySink is a Sink keeping the generated files in memory.
+type MemorySink struct {
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemorySink returns an empty MemorySink.
+func NewMemorySink() *MemorySink { return &MemorySink{files: make(map[string][]byte)} }
+
+// WriteFile registers the content of the file (replacing the previous content if any).
+func (sink *MemorySink) WriteFile(name string, content []byte, mode fs.FileMode) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.files[name] = append([]byte(nil), content...)
+	return nil
+}
+
+// Files returns the sorted names of the files written in the sink.
+func (sink *MemorySink) Files() []string {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	result := make([]string, 0, len(sink.files))
+	for name := range sink.files {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Remove the files from the sink and returns their names and contents.
+func (sink *MemorySink) flush() map[string][]byte {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	files := sink.files
+	sink.files = make(map[string][]byte)
+	return files
+}
+
+// Content returns the content of the file written in the sink.
+func (sink *MemorySink) Content(name string) (content string, found bool) {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	bytes, found := sink.files[name]
+	return string(bytes), found
+}
+
+// NewTemplateFS creates a new Template instance reading the templates, the extensions and the files loaded or
+// included by the templates from the supplied file system instead of the disk (i.e. embed.FS or fstest.MapFS).
+// The root of the file system is used as template folder and the names are resolved relatively to it (the absolute
+// names are also resolved from the root of the file system, the disk is never accessed).
+// A sink must be defined to write the generated files (see Sink).
+func NewTemplateFS(fsys fs.FS, context interface{}, delimiters string, options OptionsSet, substitutes ...string) (*Template, error) {
+	return newTemplate(fsys, fsVirtualRoot, context, delimiters, options, nil, substitutes...)
+}
+
+// The template folder of the templates created from a file system. This is a virtual folder used to express the
+// absolute names of the files of the file system, it is not supposed to exist on disk.
+var fsVirtualRoot = filepath.FromSlash("/gotemplate-fs")
+
+// Sink set the destination of the files generated by the template (default to the disk).
+func (t *Template) Sink(sink Sink) *Template {
+	t.sink = sink
+	return t
+}
+
+// Output set the writer receiving the results printed by the template (default to stdout).
+func (t *Template) Output(writer io.Writer) *Template {
+	t.output = writer
+	return t
+}
+
+// LoadData returns a go representation of the supplied file (YAML, JSON or HCL) read from the template file system.
+func (t *Template) LoadData(filename string, out interface{}) error {
+	content, err := t.readFile(filename)
+	if err != nil {
+		return err
+	}
+	return collections.ConvertData(string(content), out)
+}
+
+// Returns the name of the file in the template file system.
+func (t *Template) fsName(name string) string {
+	if filepath.IsAbs(name) {
+		if relative, err := filepath.Rel(t.fsRoot, name); err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			name = relative
+		} else {
+			// The absolute names outside of the virtual root are resolved from the root of the file system
+			name = strings.TrimLeft(strings.TrimPrefix(name, filepath.VolumeName(name)), `/\`)
+		}
+	}
+	return path.Clean(filepath.ToSlash(name))
+}
+
+// Returns the absolute name of a file of the template file system (the names on disk are left unchanged).
+func (t *Template) fsAbs(name string) string {
+	if t.fsys == nil || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(t.fsRoot, filepath.FromSlash(name))
+}
+
+func (t *Template) readFile(name string) ([]byte, error) {
+	if t.fsys == nil {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(t.fsys, t.fsName(name))
+}
+
+func (t *Template) statFile(name string) (fs.FileInfo, error) {
+	if t.fsys == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(t.fsys, t.fsName(name))
+}
+
+// Indicates if the generated files are directly written on disk (in that case, the original files could also be renamed or removed).
+func (t *Template) writesOnDisk() bool { return t.sink == nil && t.fsys == nil && !t.options[DryRun] }
+
+func (t *Template) writeFile(name string, content []byte, mode fs.FileMode) error {
+	// A template that has been abandoned after a timeout may still be running, but it must not have side effects anymore
+	if err := t.interrupted(fmt.Sprintf("write of %s", name)); err != nil {
+		return err
+	}
+	if t.options[DryRun] {
+		// The files are kept in memory to be compared with the existing files once the templates are processed
+		return t.unsaved.WriteFile(name, content, mode)
+	}
+	if t.sink != nil {
+		return t.sink.WriteFile(filepath.ToSlash(utils.Relative(t.fsRoot, name)), content, mode)
+	}
+	if t.fsys != nil {
+		return fmt.Errorf("cannot write %s, no sink has been defined for the template file system", name)
+	}
+	return os.WriteFile(name, content, mode)
+}
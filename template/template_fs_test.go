@@ -0,0 +1,89 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"ext/greeting.gte":  {Data: []byte(`@define("greeting")Hello {{ .name }}@end`)},
+		"data.yml":          {Data: []byte("name: World\n")},
+		"sub/header.txt":    {Data: []byte("Header")},
+		"sub/page.txt.gt":   {Data: []byte(`@include("header.txt") @include("greeting", data(load("/data.yml")))`)},
+		"sub/binary.txt.gt": {Data: []byte(`@len(load("/data.yml", true))`)},
+		"saved.gt":          {Data: []byte(`@save("out/saved.txt", "Saved")`)},
+	}
+
+	template, err := NewTemplateFS(fsys, nil, "", DefaultOptions().Set(Overwrite))
+	assert.NoError(t, err)
+	sink := NewMemorySink()
+	template.Sink(sink)
+
+	files, err := template.ProcessTemplates("", "", "sub/page.txt.gt", "sub/binary.txt.gt", "saved.gt")
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, []string{"out/saved.txt", "sub/binary.txt", "sub/page.txt"}, sink.Files())
+	for name, want := range map[string]string{
+		"sub/page.txt":   "Header Hello World",
+		"sub/binary.txt": "12",
+		"out/saved.txt":  "Saved",
+	} {
+		content, found := sink.Content(name)
+		assert.True(t, found, name)
+		assert.Equal(t, want, content, name)
+	}
+
+	var data map[string]interface{}
+	assert.NoError(t, template.LoadData("data.yml", &data))
+	assert.Equal(t, "World", data["name"])
+
+	var output bytes.Buffer
+	_, err = template.Output(&output).ProcessTemplates("", "", `@include("greeting", dict("name", "you"))`)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello you\n", output.String())
+
+	withoutSink, err := NewTemplateFS(fsys, nil, "", nil)
+	assert.NoError(t, err)
+	_, err = withoutSink.ProcessTemplates("", "", "sub/header.txt")
+	assert.NoError(t, err, "Unchanged files are not written")
+	_, err = withoutSink.ProcessTemplates("", "", "saved.gt")
+	assert.ErrorContains(t, err, "no sink has been defined for the template file system")
+}
+
+func TestTemplateFSDoesNotReadDisk(t *testing.T) {
+	folder := t.TempDir()
+	secret := filepath.Join(folder, "secret.txt")
+	assert.NoError(t, os.WriteFile(secret, []byte("secret"), 0644))
+	fsys := fstest.MapFS{"data.yml": {Data: []byte("name: World\n")}}
+
+	for _, sandbox := range []bool{false, true} {
+		options := DefaultOptions()
+		options[Sandbox] = sandbox
+		options[StrictErrorCheck] = true
+		template, err := NewTemplateFS(fsys, nil, "", options)
+		assert.NoError(t, err)
+		for _, tt := range []struct {
+			content string
+			want    string
+		}{
+			{`@exists("/data.yml") @exists("data.yml") @size("data.yml") @glob("/*.yml")`, `true true 12 ["data.yml"]`},
+			{fmt.Sprintf(`@exists(%q) @glob(%q)`, secret, filepath.Join(folder, "*")), "false []"},
+			{fmt.Sprintf(`@exists(%q)`, filepath.Join(fsVirtualRoot, "..", secret)), "false"},
+		} {
+			result, err := template.ProcessContent(tt.content, "test")
+			assert.NoError(t, err, tt.content)
+			assert.Equal(t, tt.want, result, tt.content)
+		}
+		_, err = template.ProcessContent(fmt.Sprintf(`@load(%q)`, secret), "test")
+		assert.Error(t, err)
+		_, err = template.ProcessContent(fmt.Sprintf(`@include(%q)`, secret), "test")
+		assert.Error(t, err)
+	}
+}
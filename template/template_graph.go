@@ -0,0 +1,199 @@
+package template
+
+import (
+	"path/filepath"
+	"sort"
+	"text/template/parse"
+
+	"github.com/coveooss/gotemplate/v3/utils"
+	"github.com/coveooss/multilogger/errors"
+)
+
+// DependencyKind indicates the nature of a dependency.
+type DependencyKind string
+
+// DependencyKind values
+const (
+	DependencyTemplate   DependencyKind = "template"   // Sub-template invoked through template or include
+	DependencyDefinition DependencyKind = "definition" // File where a sub-template is defined
+	DependencyInclude    DependencyKind = "include"    // File included
+	DependencyData       DependencyKind = "data"       // File read with load
+	DependencyOutput     DependencyKind = "output"     // File written with save
+	DependencyCommand    DependencyKind = "command"    // Command executed with exec or run
+)
+
+// Dependency describes a dependency between a template (file or sub-template) and another element.
+type Dependency struct {
+	From string         `json:"from"` // The template file or the sub-template name
+	To   string         `json:"to"`   // The sub-template name, the file or the command
+	Kind DependencyKind `json:"kind"`
+}
+
+// Dependencies applies the razor conversion and parses the supplied templates (without executing them) to find the
+// sub-templates, the files and the commands they depend on. Only the calls to template, include, load, save, exec
+// and run (or their aliases) with literal arguments are considered. The sub-templates defined by the extensions are
+// also analyzed. Parsing errors are returned, but they do not prevent the analysis of the other templates.
+func (t *Template) Dependencies(templates ...string) ([]Dependency, error) {
+	graph := dependencyGraph{Template: t, found: make(map[Dependency]bool)}
+
+	// The sub-templates loaded from the extensions files are already registered in the main template
+	for _, tpl := range t.Templates() {
+		if tpl.Name() != tpl.ParseName && tpl.Tree != nil {
+			graph.add(tpl.Name(), utils.Relative(t.folder, tpl.ParseName), DependencyDefinition)
+			graph.walk(t, tpl.Name(), tpl.Tree.Root)
+		}
+	}
+
+	var errs errors.Array
+	for _, template := range templates {
+		if err := graph.analyze(template); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	sort.Slice(graph.dependencies, func(i, j int) bool {
+		a, b := graph.dependencies[i], graph.dependencies[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.To < b.To
+	})
+	return graph.dependencies, errs.AsError()
+}
+
+type dependencyGraph struct {
+	*Template
+	dependencies []Dependency
+	found        map[Dependency]bool
+}
+
+func (g *dependencyGraph) add(from, to string, kind DependencyKind) {
+	dependency := Dependency{from, to, kind}
+	if !g.found[dependency] {
+		g.found[dependency] = true
+		g.dependencies = append(g.dependencies, dependency)
+	}
+}
+
+func (g *dependencyGraph) analyze(template string) error {
+	source, filename := template, "."
+	if content, err := g.readFile(template); err == nil {
+		source, filename = string(content), template
+	} else if !g.IsCode(template) {
+		return err
+	}
+
+	code, _ := g.prepareCode(source)
+	if !g.IsCode(code) {
+		return nil
+	}
+
+	context := g.GetNewContext(filepath.Dir(filename), false)
+	newTemplate := context.New(filename)
+	var err error
+	func() {
+		// We cannot call the parser without locking
+		templateMutex.Lock()
+		defer templateMutex.Unlock()
+		newTemplate, err = context.parseCode(newTemplate, code)
+	}()
+	if err != nil {
+		return err
+	}
+
+	for _, tpl := range newTemplate.Templates() {
+		if tpl.ParseName != filename || tpl.Tree == nil {
+			// The sub-templates that are not defined in the current file have already been analyzed
+			continue
+		}
+		if tpl.Name() != filename {
+			g.add(tpl.Name(), filename, DependencyDefinition)
+		}
+		g.walk(context, tpl.Name(), tpl.Tree.Root)
+	}
+	return nil
+}
+
+// Walk the parse tree to find the dependencies of the template named from.
+func (g *dependencyGraph) walk(context *Template, from string, node parse.Node) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node != nil {
+			for _, child := range node.Nodes {
+				g.walk(context, from, child)
+			}
+		}
+	case *parse.ActionNode:
+		g.walk(context, from, node.Pipe)
+	case *parse.IfNode:
+		g.walkBranch(context, from, &node.BranchNode)
+	case *parse.RangeNode:
+		g.walkBranch(context, from, &node.BranchNode)
+	case *parse.WithNode:
+		g.walkBranch(context, from, &node.BranchNode)
+	case *parse.TemplateNode:
+		g.add(from, node.Name, DependencyTemplate)
+		g.walk(context, from, node.Pipe)
+	case *parse.PipeNode:
+		if node != nil {
+			for _, command := range node.Cmds {
+				g.walk(context, from, command)
+			}
+		}
+	case *parse.ChainNode:
+		g.walk(context, from, node.Node)
+	case *parse.CommandNode:
+		g.command(context, from, node)
+		for _, arg := range node.Args {
+			g.walk(context, from, arg)
+		}
+	}
+}
+
+func (g *dependencyGraph) walkBranch(context *Template, from string, node *parse.BranchNode) {
+	g.walk(context, from, node.Pipe)
+	g.walk(context, from, node.List)
+	g.walk(context, from, node.ElseList)
+}
+
+// Register the dependency if the command is a call to a function that refers to a template, a file or a command.
+func (g *dependencyGraph) command(context *Template, from string, node *parse.CommandNode) {
+	function, isIdentifier := node.Args[0].(*parse.IdentifierNode)
+	if !isIdentifier {
+		return
+	}
+	funcInfo := context.functions[function.Ident]
+	if funcInfo == nil {
+		return
+	}
+	position := 1
+	if realName := funcInfo.RealName(); realName == "execWith" || realName == "runWith" {
+		// The first argument is the options dictionary
+		position = 2
+	}
+	if len(node.Args) <= position {
+		return
+	}
+	argument, isString := node.Args[position].(*parse.StringNode)
+	if !isString {
+		return
+	}
+
+	switch funcInfo.RealName() {
+	case "include":
+		if context.Lookup(argument.Text) != nil {
+			g.add(from, argument.Text, DependencyTemplate)
+		} else {
+			g.add(from, argument.Text, DependencyInclude)
+		}
+	case "load":
+		g.add(from, argument.Text, DependencyData)
+	case "save":
+		g.add(from, argument.Text, DependencyOutput)
+	case "exec", "run", "execWith", "runWith":
+		g.add(from, argument.Text, DependencyCommand)
+	}
+}
@@ -0,0 +1,42 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencies(t *testing.T) {
+	folder := t.TempDir()
+	write := func(name, content string) string {
+		file := filepath.Join(folder, name)
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		return file
+	}
+	write("ext.gte", `@define("header")@load("header.yaml")@end`)
+	main := write("main.gt", `@include("header")
+@define("local")@exec("git status")@end
+{{ template "local" }}
+@if (true)@save("out.txt", "content")@end
+@include("other.txt")
+@read("data.json")
+@load(dynamic)`)
+	invalid := write("invalid.gt", "{{ end }}")
+
+	template := MustNewTemplate(folder, nil, "", nil)
+	dependencies, err := template.Dependencies(main, invalid)
+	assert.Error(t, err, "The parsing error is reported")
+	assert.Equal(t, []Dependency{
+		{main, "data.json", DependencyData},
+		{main, "other.txt", DependencyInclude},
+		{main, "out.txt", DependencyOutput},
+		{main, "header", DependencyTemplate},
+		{main, "local", DependencyTemplate},
+		{"header", "header.yaml", DependencyData},
+		{"header", "ext.gte", DependencyDefinition},
+		{"local", "git status", DependencyCommand},
+		{"local", main, DependencyDefinition},
+	}, dependencies)
+}
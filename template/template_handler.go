@@ -2,11 +2,14 @@ package template
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/coveooss/gotemplate/v3/collections"
 	"github.com/coveooss/gotemplate/v3/utils"
@@ -18,34 +21,145 @@ import (
 type CustomHandler func(name, original string, result *string, changed bool, status error) (bool, error)
 
 // ProcessTemplatesWithHandler loads and runs the file template or execute the content if it is not a file and call the custom handler between after each template.
+// If more than one job has been configured (see Jobs), the templates are processed concurrently and the handler may be called from multiple goroutines.
+// The output and the returned files are always reported in the same order as the supplied templates.
 func (t *Template) ProcessTemplatesWithHandler(sourceFolder, targetFolder string, handler CustomHandler, templates ...string) (resultFiles []string, err error) {
 	sourceFolder = iif(sourceFolder == "", t.folder, sourceFolder).(string)
 	targetFolder = iif(targetFolder == "", t.folder, targetFolder).(string)
 	resultFiles = make([]string, 0, len(templates))
 
-	print := t.options[OutputStdout]
+	type processResult struct {
+		file   string
+		err    error
+		output *bytes.Buffer
+	}
+	results := make([]processResult, len(templates))
+	jobs := t.jobs
+	if t.options[Deterministic] {
+		// The random values must always be drawn in the same order
+		jobs = 1
+	}
+	process := func(i int) {
+		// Some file may change the options at runtime, so each file is processed with its own copy of the options
+		fileTemplate := t.fileContext()
+		if jobs > 1 {
+			// The folder contexts, the functions and the global context cannot be shared between files processed
+			// concurrently since the running templates alter them (i.e. alias or global assignation)
+			fileTemplate.isolate()
+			// The output is buffered to ensure that it is not interleaved with the output of other files
+			results[i].output = new(bytes.Buffer)
+			fileTemplate.output = results[i].output
+		}
+		if t.templateTimeout > 0 {
+			ctx, cancel := context.WithTimeout(t.getRunContext(), t.templateTimeout)
+			defer cancel()
+			fileTemplate = fileTemplate.withContext(ctx)
+		}
+		results[i].file, results[i].err = fileTemplate.processTemplate(templates[i], sourceFolder, targetFolder, handler)
+	}
+
+	if jobs > 1 {
+		var wg sync.WaitGroup
+		queue := make(chan int)
+		for worker := 0; worker < jobs && worker < len(templates); worker++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range queue {
+					process(i)
+				}
+			}()
+		}
+		for i := range templates {
+			queue <- i
+		}
+		close(queue)
+		wg.Wait()
+	} else {
+		for i := range templates {
+			process(i)
+		}
+	}
 
 	var errors errors.Array
-	for i := range templates {
-		t.options[OutputStdout] = print // Some file may change this option at runtime, so we restore it back to its originalSourceLines value between each file
-		resultFile, err := t.processTemplate(templates[i], sourceFolder, targetFolder, handler)
-		if err == nil {
-			if resultFile != "" {
-				resultFiles = append(resultFiles, resultFile)
+	for _, result := range results {
+		if result.output != nil && result.output.Len() > 0 {
+			t.print(result.output.String())
+		}
+		if result.err == nil {
+			if result.file != "" {
+				resultFiles = append(resultFiles, result.file)
 			}
 		} else {
+			errors = append(errors, result.err)
+		}
+	}
+	if t.options[DryRun] {
+		// The files saved by the templates are reported as if they were rendered files
+		saved, err := t.diffUnsaved()
+		resultFiles = append(resultFiles, saved...)
+		if err != nil {
 			errors = append(errors, err)
 		}
 	}
 	return resultFiles, errors.AsError()
 }
 
+// Print the differences between the files saved by the templates in dry run mode and the existing files.
+// It returns the name of the files that would be modified.
+func (t *Template) diffUnsaved() (modifiedFiles []string, err error) {
+	files := t.unsaved.flush()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var modified string
+		if modified, err = t.printDiff(name, string(files[name])); err != nil {
+			return
+		}
+		if modified != "" {
+			modifiedFiles = append(modifiedFiles, modified)
+		}
+	}
+	return
+}
+
+// Returns a copy of the template that can be used to process a single file without altering the options of the original template.
+func (t *Template) fileContext() *Template {
+	fileTemplate := *t
+	fileTemplate.options = make(OptionsSet, len(t.options))
+	for k, v := range t.options {
+		fileTemplate.options[k] = v
+	}
+	return &fileTemplate
+}
+
+// Gives the template its own copy of the folder contexts, the functions, the aliases and the context.
+func (t *Template) isolate() {
+	templateMutex.Lock()
+	defer templateMutex.Unlock()
+	t.children = make(map[string]*Template)
+	t.functions = t.functions.clone()
+	t.aliases = t.aliases.clone()
+	namespaces := make(map[string]funcTableMap, len(t.namespaces))
+	for namespace, functions := range t.namespaces {
+		namespaces[namespace] = functions.clone()
+	}
+	t.namespaces = namespaces
+	if context, err := collections.TryAsDictionary(t.context); err == nil {
+		t.context = context.Clone()
+	}
+}
+
 func (t *Template) processTemplate(template, sourceFolder, targetFolder string, handler CustomHandler) (resultFile string, err error) {
 	isCode := t.IsCode(template)
 	var content string
 
-	if fileContent, fileError := os.ReadFile(template); fileError == nil {
+	if fileContent, fileError := t.readFile(template); fileError == nil {
 		content = string(fileContent)
+		template = t.fsAbs(template)
 		isCode = false
 	} else if isCode {
 		content = template
@@ -55,6 +169,7 @@ func (t *Template) processTemplate(template, sourceFolder, targetFolder string,
 		return
 	}
 
+	t.reseed(template)
 	result, changed, err := t.processContentInternal(content, template, nil, 0, true, handler)
 	if err != nil {
 		return
@@ -70,9 +185,9 @@ func (t *Template) processTemplate(template, sourceFolder, targetFolder string,
 
 		// Avoid adding an extra blank line if the result already ends with a newline
 		if !strings.HasSuffix(result, "\n") {
-			Println(result)
+			t.println(result)
 		} else {
-			Print(result)
+			t.print(result)
 		}
 		return
 	}
@@ -95,6 +210,11 @@ func (t *Template) processTemplate(template, sourceFolder, targetFolder string,
 	}
 
 	if t.options[OutputStdout] {
+		if t.options[DryRun] {
+			// A gotemplate script (shebang) has no target file, only the files that it saves are reported in dry run mode
+			resultFile = ""
+			return
+		}
 		err = t.printResult(template, resultFile, result, changed)
 		if err != nil {
 			errors.Print(err)
@@ -103,19 +223,29 @@ func (t *Template) processTemplate(template, sourceFolder, targetFolder string,
 		return
 	}
 
+	if t.options[DryRun] {
+		// We do not write anything, we only report the differences with the current target file
+		resultFile, err = t.printDiff(resultFile, result)
+		return
+	}
+
 	if sourceFolder == targetFolder && !changed {
 		resultFile = ""
 		return
 	}
 
-	mode := must(os.Stat(template)).(os.FileInfo).Mode()
-
-	if sourceFolder != targetFolder {
-		must(os.MkdirAll(filepath.Dir(resultFile), 0777))
-	} else if !isTemplate && !t.options[Overwrite] {
-		newName := template + ".original"
-		InternalLog.Infof("%s => %s", utils.Relative(t.folder, template), utils.Relative(t.folder, newName))
-		must(os.Rename(template, template+".original"))
+	mode := must(t.statFile(template)).(os.FileInfo).Mode()
+	var original string
+
+	if t.writesOnDisk() {
+		// The original files are left unchanged when the result is sent to a sink
+		if sourceFolder != targetFolder {
+			must(os.MkdirAll(filepath.Dir(resultFile), 0777))
+		} else if !isTemplate && !t.options[Overwrite] {
+			original = template + ".original"
+			InternalLog.Infof("%s => %s", utils.Relative(t.folder, template), utils.Relative(t.folder, original))
+			must(os.Rename(template, original))
+		}
 	}
 	InternalLog.Infoln("Writing file", utils.Relative(t.folder, resultFile))
 
@@ -123,11 +253,12 @@ func (t *Template) processTemplate(template, sourceFolder, targetFolder string,
 		mode = 0755
 	}
 
-	if err = os.WriteFile(resultFile, []byte(result), mode); err != nil {
+	if err = t.writeFile(resultFile, []byte(result), mode); err != nil {
 		return
 	}
+	t.manifest.add(resultFile, template, original)
 
-	if isTemplate && t.options[Overwrite] && sourceFolder == targetFolder {
+	if isTemplate && t.options[Overwrite] && sourceFolder == targetFolder && t.writesOnDisk() {
 		os.Remove(template)
 	}
 	return
@@ -146,14 +277,9 @@ func (t *Template) processContentInternal(originalContent, source string, origin
 	topCall := th.Lines == nil
 
 	pausingIsEnabled := strings.Contains(originalContent, pauseGoTemplate) || strings.Contains(originalContent, pauseRazor)
-
-	// When pausing templating, we replace delimiters with dummy strings and then we revert the replacements when the processing is complete
-	leftDelimReplacement, rightDelimReplacement, razorDelimReplacement := "$&paused-left&$", "$&paused-right&$", "$&paused-razor&$"
 	revertReplacements := func(template string) string {
 		if pausingIsEnabled {
-			template = strings.ReplaceAll(template, leftDelimReplacement, t.LeftDelim())
-			template = strings.ReplaceAll(template, rightDelimReplacement, t.RightDelim())
-			template = strings.ReplaceAll(template, razorDelimReplacement, t.RazorDelim())
+			template = t.revertPausedDelimiters(template)
 		}
 		return template
 	}
@@ -167,38 +293,11 @@ func (t *Template) processContentInternal(originalContent, source string, origin
 			}()
 		}
 
-		th.Code = t.substitute(th.Code)
-
-		if strings.HasPrefix(th.Code, "#!") {
-			// If the content starts with a Shebang operator including gotemplate, we remove the first line
-			lines := strings.Split(th.Code, "\n")
-			if strings.Contains(lines[0], "gotemplate") {
-				th.Code = strings.Join(lines[1:], "\n")
-				t.options[OutputStdout] = true
-			}
-		}
-
-		if pausingIsEnabled {
-			splitLines := strings.Split(th.Code, "\n")
-			isGoTemplatePaused, isRazorPaused := false, false
-			for index, line := range splitLines {
-				isGoTemplatePaused = (isGoTemplatePaused || strings.Contains(line, pauseGoTemplate)) && !strings.Contains(line, resumeGoTemplate)
-				isRazorPaused = (isRazorPaused || strings.Contains(line, pauseRazor)) && !strings.Contains(line, resumeRazor)
-				if isRazorPaused || isGoTemplatePaused {
-					splitLines[index] = strings.ReplaceAll(splitLines[index], t.RazorDelim(), razorDelimReplacement)
-				}
-				if isGoTemplatePaused {
-					splitLines[index] = strings.ReplaceAll(splitLines[index], t.LeftDelim(), leftDelimReplacement)
-					splitLines[index] = strings.ReplaceAll(splitLines[index], t.RightDelim(), rightDelimReplacement)
-				}
-			}
-			th.Code = strings.Join(splitLines, "\n")
+		var shebang bool
+		if th.Code, shebang = t.prepareCode(th.Code); shebang {
+			t.options[OutputStdout] = true
 		}
 
-		var razor []byte
-		razor, _ = t.applyRazor([]byte(th.Code))
-		th.Code = string(razor)
-
 		if t.options[RenderingDisabled] || !t.IsCode(th.Code) {
 			// There is no template element to evaluate or the template rendering is off
 			return revertReplacements(th.Code), false, nil
@@ -221,8 +320,11 @@ func (t *Template) processContentInternal(originalContent, source string, origin
 				strictMode = strictMode || strings.Contains(th.Source, explicitGoTemplate)
 				extension := filepath.Ext(th.Filename)
 				strictMode = strictMode || (extension != "" && strings.Contains(".gt,.gte,.template", extension))
+				// An interrupted processing is always reported
+				strictMode = strictMode || t.getRunContext().Err() != nil
 				if !(strictMode) {
 					InternalLog.Errorf("Ignored gotemplate error in %s (file left unchanged):\n%s", color.CyanString(th.Filename), err.Error())
+					t.warnings.add(err)
 					result, err = th.Source, nil
 					changed = false
 				}
@@ -231,6 +333,7 @@ func (t *Template) processContentInternal(originalContent, source string, origin
 	}
 
 	context := t.GetNewContext(filepath.Dir(th.Filename), true)
+	context.currentFile = th.Filename
 	newTemplate := context.New(th.Filename)
 	newTemplate.Option("missingkey=default")
 
@@ -239,7 +342,7 @@ func (t *Template) processContentInternal(originalContent, source string, origin
 		// call the parser without locking
 		templateMutex.Lock()
 		defer templateMutex.Unlock()
-		newTemplate, err = newTemplate.Parse(th.Code)
+		newTemplate, err = context.parseCode(newTemplate, th.Code)
 	}()
 	if err != nil {
 		InternalLog.Debugf("%s(%d): Parsing error %v", th.Filename, th.Try, err)
@@ -251,7 +354,7 @@ func (t *Template) processContentInternal(originalContent, source string, origin
 	if cloneContext {
 		workingContext = collections.AsDictionary(workingContext).Clone()
 	}
-	if err = newTemplate.Execute(&out, workingContext); err != nil {
+	if err = t.execute(newTemplate, &out, workingContext); err != nil {
 		InternalLog.Debugf("%s(%d): Execution error %v", th.Filename, th.Try, err)
 		return th.Handler(err)
 	}
@@ -272,11 +375,67 @@ func (t *Template) processContentInternal(originalContent, source string, origin
 		s = s.Replace(noValueRepl, noValue).Replace(nilValueRepl, nilValue)
 
 		if count > 0 {
-			// If there are invalid values, we set the error message
-			err = fmt.Errorf("template: %s:: %s\n%s", th.Filename, noValueError, color.HiBlackString(s.AddLineNumber(0).Str()))
+			// If there are invalid values, we set the error message (the line refers to the result, not to the source)
+			var excerpt string
+			for _, line := range strings.Split(result, "\n") {
+				if strings.Contains(line, noValue) || strings.Contains(line, nilValue) {
+					excerpt = strings.TrimSpace(line)
+					break
+				}
+			}
+			err = newTemplateError(fmt.Errorf("template: %s:: %s\n%s", th.Filename, noValueError, color.HiBlackString(s.AddLineNumber(0).Str())), th.Filename, 0, 0, excerpt, noValueError)
 		}
 		result = s.Str()
 	}
 	changed = result != originalContent
 	return
 }
+
+// When pausing templating, we replace delimiters with dummy strings and then we revert the replacements when the processing is complete
+const (
+	leftDelimReplacement  = "$&paused-left&$"
+	rightDelimReplacement = "$&paused-right&$"
+	razorDelimReplacement = "$&paused-razor&$"
+)
+
+// Apply the substitutions, the processing pauses and the razor conversion to the supplied code.
+// It also returns true if the code was starting with a gotemplate shebang (the shebang line is then removed).
+func (t *Template) prepareCode(code string) (result string, shebang bool) {
+	code = t.substitute(code)
+
+	if strings.HasPrefix(code, "#!") {
+		// If the content starts with a Shebang operator including gotemplate, we remove the first line
+		lines := strings.Split(code, "\n")
+		if strings.Contains(lines[0], "gotemplate") {
+			code = strings.Join(lines[1:], "\n")
+			shebang = true
+		}
+	}
+
+	if strings.Contains(code, pauseGoTemplate) || strings.Contains(code, pauseRazor) {
+		splitLines := strings.Split(code, "\n")
+		isGoTemplatePaused, isRazorPaused := false, false
+		for index, line := range splitLines {
+			isGoTemplatePaused = (isGoTemplatePaused || strings.Contains(line, pauseGoTemplate)) && !strings.Contains(line, resumeGoTemplate)
+			isRazorPaused = (isRazorPaused || strings.Contains(line, pauseRazor)) && !strings.Contains(line, resumeRazor)
+			if isRazorPaused || isGoTemplatePaused {
+				splitLines[index] = strings.ReplaceAll(splitLines[index], t.RazorDelim(), razorDelimReplacement)
+			}
+			if isGoTemplatePaused {
+				splitLines[index] = strings.ReplaceAll(splitLines[index], t.LeftDelim(), leftDelimReplacement)
+				splitLines[index] = strings.ReplaceAll(splitLines[index], t.RightDelim(), rightDelimReplacement)
+			}
+		}
+		code = strings.Join(splitLines, "\n")
+	}
+
+	razor, _ := t.applyRazor([]byte(code))
+	return string(razor), shebang
+}
+
+// Restore the delimiters that have been replaced in paused sections by prepareCode.
+func (t *Template) revertPausedDelimiters(code string) string {
+	code = strings.ReplaceAll(code, leftDelimReplacement, t.LeftDelim())
+	code = strings.ReplaceAll(code, rightDelimReplacement, t.RightDelim())
+	return strings.ReplaceAll(code, razorDelimReplacement, t.RazorDelim())
+}
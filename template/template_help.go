@@ -11,6 +11,70 @@ import (
 	"github.com/fatih/color"
 )
 
+// FunctionDescription contains the information exposed by FuncInfo in a serializable form (without color).
+type FunctionDescription struct {
+	Name        string    `json:"name" yaml:"name"`
+	Group       string    `json:"group" yaml:"group"`
+	Namespace   string    `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Signature   string    `json:"signature" yaml:"signature"`
+	Arguments   string    `json:"arguments" yaml:"arguments"`
+	Result      string    `json:"result" yaml:"result"`
+	Aliases     []string  `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	Examples    []Example `json:"examples,omitempty" yaml:"examples,omitempty"`
+	IsAlias     bool      `json:"isAlias" yaml:"isAlias"`
+	AliasOf     string    `json:"aliasOf,omitempty" yaml:"aliasOf,omitempty"` // The name of the real function if the entry is an alias
+}
+
+// TemplateDescription identifies a template and the file where it is defined.
+type TemplateDescription struct {
+	Name string `json:"name" yaml:"name"`
+	File string `json:"file" yaml:"file"`
+}
+
+// DescribeTemplates returns the list of templates available (as PrintTemplates, the main templates are only included
+// if all is set).
+func (t *Template) DescribeTemplates(all bool) []TemplateDescription {
+	result := make([]TemplateDescription, 0)
+	for _, template := range t.getTemplateNames() {
+		tpl := t.Lookup(template)
+		if all || tpl.Name() != tpl.ParseName {
+			name, file := tpl.Name(), utils.Relative(t.folder, tpl.ParseName)
+			if name != "." || file != "." {
+				result = append(result, TemplateDescription{name, file})
+			}
+		}
+	}
+	return result
+}
+
+// DescribeFunctions returns the description of the functions matching the filters (as PrintFunctions, the aliases
+// are only included if all is set).
+func (t *Template) DescribeFunctions(all bool, filters ...string) []FunctionDescription {
+	t.completeExamples()
+	functions := t.filterFunctions(all, true, true, filters...)
+	result := make([]FunctionDescription, len(functions))
+	for i := range functions {
+		fi := t.functions[functions[i]]
+		result[i] = FunctionDescription{
+			Name:        fi.Name(),
+			Group:       fi.Group(),
+			Namespace:   fi.Namespace(),
+			Signature:   striptColor(fi.Signature()),
+			Arguments:   striptColor(fi.Arguments()),
+			Result:      fi.Result(),
+			Aliases:     fi.Aliases(),
+			Description: fi.Description(),
+			Examples:    ifUndef(fi, fi.alias).(*FuncInfo).Examples(),
+			IsAlias:     fi.IsAlias(),
+		}
+		if fi.IsAlias() {
+			result[i].AliasOf = fi.RealName()
+		}
+	}
+	return result
+}
+
 // PrintTemplates output the list of templates available.
 func (t *Template) PrintTemplates(all, long bool) {
 	templates := t.getTemplateNames()
@@ -84,6 +148,11 @@ func (t *Template) PrintFunctions(all, long, groupByCategory bool, filters ...st
 	}
 }
 
+// FindFunctions returns the name of the functions matching one of the filters (name, alias, category or description).
+func (t *Template) FindFunctions(filters ...string) []string {
+	return t.filterFunctions(false, true, true, filters...)
+}
+
 func (t *Template) filterFunctions(all, category, detailed bool, filters ...string) []string {
 	functions := t.getAllFunctions()
 	if all && len(filters) == 0 {
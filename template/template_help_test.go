@@ -0,0 +1,39 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	folder := t.TempDir()
+	extension := `@define("greet")Hello @.name@end`
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "ext.gte"), []byte(extension), 0644))
+	template := MustNewTemplate(folder, nil, "", nil)
+
+	assert.Equal(t, []TemplateDescription{{"greet", "ext.gte"}}, template.DescribeTemplates(false))
+
+	functions := template.DescribeFunctions(true, "date_in_zone")
+	if assert.Len(t, functions, 2) {
+		assert.Equal(t, FunctionDescription{
+			Name:        "dateInZone",
+			Group:       "Sprig Date, http://masterminds.github.io/sprig/date.html",
+			Namespace:   "sprig",
+			Signature:   "dateInZone(fmt string, date interface{}, zone string) string",
+			Arguments:   "fmt string, date interface{}, zone string",
+			Result:      "string",
+			Aliases:     []string{"date_in_zone"},
+			Description: "Same as date, but with a timezone.",
+		}, functions[0])
+		assert.True(t, functions[1].IsAlias)
+		assert.Equal(t, "dateInZone", functions[1].AliasOf)
+		assert.Equal(t, "date_in_zone(fmt string, date interface{}, zone string) string", functions[1].Signature)
+	}
+
+	functions = template.DescribeFunctions(false, "hasKey")
+	assert.Equal(t, "@hasKey(dict(\"key\", \"value\"), \"key\")", functions[0].Examples[0].Razor)
+	assert.Equal(t, "true", functions[0].Examples[0].Result, "Examples are completed")
+}
@@ -36,10 +36,24 @@ const (
 
 // ProcessContent loads and runs the file template.
 func (t *Template) ProcessContent(content, source string) (result string, err error) {
+	t.reseed(source)
 	result, _, err = t.processContentInternal(content, source, nil, 0, true, nil)
 	return
 }
 
+// EvaluateContent runs the content against the template context itself instead of a copy of it.
+// The values assigned in the global context (i.e. @x := value) are then kept for the subsequent evaluations.
+func (t *Template) EvaluateContent(content, source string) (result string, err error) {
+	result, _, err = t.processContentInternal(content, source, nil, 0, false, nil)
+	return
+}
+
+// ConvertRazor returns the go template code generated from the supplied razor code (as rendered with the --disable option).
+func (t *Template) ConvertRazor(code string) string {
+	code, _ = t.prepareCode(code)
+	return t.revertPausedDelimiters(code)
+}
+
 // ProcessTemplate loads and runs the template if it is a file, otherwise, it simply process the content.
 func (t *Template) ProcessTemplate(template, sourceFolder, targetFolder string) (resultFile string, err error) {
 	return t.processTemplate(template, sourceFolder, targetFolder, nil)
@@ -50,18 +64,29 @@ func (t *Template) ProcessTemplates(sourceFolder, targetFolder string, templates
 	return t.ProcessTemplatesWithHandler(sourceFolder, targetFolder, nil, templates...)
 }
 
+// Apply the formatting that is normally done on generated files (i.e. terraform fmt) to the result in memory.
+func (t *Template) formatResult(target, result string) (string, error) {
+	if !utils.IsTerraformFile(target) {
+		return result, nil
+	}
+	base := filepath.Base(target)
+	tempFolder, err := os.MkdirTemp(t.tempFolder, base)
+	if err != nil {
+		return result, err
+	}
+	defer os.RemoveAll(tempFolder)
+	tempFile := filepath.Join(tempFolder, base)
+	if err = os.WriteFile(tempFile, []byte(result), 0644); err != nil {
+		return result, err
+	}
+	err = utils.TerraformFormat(tempFile)
+	bytes := must(os.ReadFile(tempFile)).([]byte)
+	return string(bytes), err
+}
+
 func (t *Template) printResult(source, target, result string, changed bool) (err error) {
-	if utils.IsTerraformFile(target) {
-		base := filepath.Base(target)
-		tempFolder := must(os.CreateTemp(t.tempFolder, base)).(string)
-		tempFile := filepath.Join(tempFolder, base)
-		err = os.WriteFile(tempFile, []byte(result), 0644)
-		if err != nil {
-			return
-		}
-		err = utils.TerraformFormat(tempFile)
-		bytes := must(os.ReadFile(tempFile)).([]byte)
-		result = string(bytes)
+	if result, err = t.formatResult(target, result); err != nil {
+		return
 	}
 
 	if changed && !t.isTemplate(source) && !t.options[Overwrite] {
@@ -77,10 +102,52 @@ func (t *Template) printResult(source, target, result string, changed bool) (err
 	} else {
 		InternalLog.Info(target)
 	}
-	Print(result)
+	t.print(result)
 	if result != "" && term.IsTerminal(int(os.Stdout.Fd())) {
-		Println()
+		t.println()
 	}
 
 	return
 }
+
+// Print the differences between the actual content of the target file and the rendered result.
+// It returns the target file name if the file would be modified by the rendering.
+func (t *Template) printDiff(target, result string) (modifiedFile string, err error) {
+	if result, err = t.formatResult(target, result); err != nil {
+		return
+	}
+	name := filepath.ToSlash(utils.Relative(t.folder, target))
+	fromFile, toFile := "a/"+name, "b/"+name
+	current, err := t.readFile(target)
+	if os.IsNotExist(err) {
+		fromFile, err = "/dev/null", nil
+	}
+	if err != nil || string(current) == result {
+		return
+	}
+
+	diff, err := utils.UnifiedDiff(fromFile, toFile, string(current), result)
+	if err != nil {
+		return
+	}
+	t.print(diff)
+	return target, nil
+}
+
+// Print the arguments on the output of the template (stdout if no specific output has been defined).
+func (t *Template) print(args ...interface{}) {
+	if t.output == nil {
+		Print(args...)
+		return
+	}
+	fmt.Fprint(t.output, args...)
+}
+
+// Print the arguments followed by a newline on the output of the template (stdout if no specific output has been defined).
+func (t *Template) println(args ...interface{}) {
+	if t.output == nil {
+		Println(args...)
+		return
+	}
+	fmt.Fprintln(t.output, args...)
+}
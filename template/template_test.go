@@ -1,8 +1,10 @@
 package template
 
 import (
+	"fmt"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -105,3 +107,137 @@ func TestTemplateAddFunctions(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "This Is My Value", result)
 }
+
+func TestTemplateFilesDryRun(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		template string
+		existing string
+		changed  bool
+	}{
+		{"New file", "@(3+4)", "", true},
+		{"Same content", "@(3+4)", "7", false},
+		{"Different content", "@(3+4)", "8", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			folder := t.TempDir()
+			source, target := path.Join(folder, "test.txt.gt"), path.Join(folder, "test.txt")
+			assert.NoError(t, os.WriteFile(source, []byte(tt.template), 0644))
+			if tt.existing != "" {
+				assert.NoError(t, os.WriteFile(target, []byte(tt.existing), 0644))
+			}
+
+			template := MustNewTemplate(folder, nil, "", nil)
+			template.SetOption(Overwrite, true)
+			template.SetOption(DryRun, true)
+			resultFiles, err := template.ProcessTemplates("", "", source)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.changed, len(resultFiles) == 1)
+
+			// Nothing should have been modified
+			_, err = os.Stat(source)
+			assert.NoError(t, err)
+			content, _ := os.ReadFile(target)
+			assert.Equal(t, tt.existing, string(content))
+		})
+	}
+}
+
+func TestTemplateFilesDryRunSave(t *testing.T) {
+	t.Parallel()
+	folder := t.TempDir()
+	source, script := path.Join(folder, "test.txt.gt"), path.Join(folder, "script.txt")
+	saved, unchanged := path.Join(folder, "sub", "saved.txt"), path.Join(folder, "script.out")
+	assert.NoError(t, os.WriteFile(source, []byte(`@save("`+saved+`", "new")rendered`), 0644))
+	assert.NoError(t, os.WriteFile(script, []byte("#! /usr/bin/env gotemplate\n@save(\""+unchanged+"\", 1)script"), 0644))
+	assert.NoError(t, os.WriteFile(unchanged, []byte("1"), 0644))
+
+	var output strings.Builder
+	template := MustNewTemplate(folder, nil, "", nil).Output(&output)
+	template.SetOption(Overwrite, true)
+	template.SetOption(DryRun, true)
+	resultFiles, err := template.ProcessTemplates("", "", source, script)
+	assert.NoError(t, err)
+
+	// The saved files are reported as the rendered files, but nothing is written (the unchanged file is not reported)
+	assert.Equal(t, []string{path.Join(folder, "test.txt"), saved}, resultFiles)
+	assert.Contains(t, output.String(), "+++ b/sub/saved.txt\n")
+	assert.NotContains(t, output.String(), "script.out")
+	assert.NotContains(t, output.String(), "script\n")
+	assert.NoDirExists(t, path.Join(folder, "sub"))
+	assert.NoFileExists(t, path.Join(folder, "test.txt"))
+}
+
+func TestTemplateFilesJobs(t *testing.T) {
+	// This test is not parallel since it replaces the global Print function to capture the output
+	var output strings.Builder
+	defer func(print func(...interface{}) (int, error)) { Print = print }(Print)
+	Print = func(args ...interface{}) (int, error) { return fmt.Fprint(&output, args...) }
+
+	folder := t.TempDir()
+	var templates []string
+	for i := 0; i < 8; i++ {
+		// The first files are the slowest ones to ensure that they do not complete in order
+		file := path.Join(folder, fmt.Sprintf("file%d.txt", i))
+		content := fmt.Sprintf(`@exec("sleep %.2f")%d`, float64(8-i)/100, i)
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		templates = append(templates, file)
+	}
+
+	// The output is reported in the same order as the templates
+	template := MustNewTemplate(folder, nil, "", nil).Jobs(4)
+	template.SetOption(OutputStdout, true)
+	resultFiles, err := template.ProcessTemplates("", "", templates...)
+	assert.NoError(t, err)
+	assert.Empty(t, resultFiles)
+	assert.Equal(t, "01234567", output.String())
+	output.Reset()
+
+	shebang := path.Join(folder, "shebang.txt")
+	assert.NoError(t, os.WriteFile(shebang, []byte("#! /usr/bin/env gotemplate\n@(1+1)"), 0644))
+	invalid := path.Join(folder, "invalid.gt")
+	assert.NoError(t, os.WriteFile(invalid, []byte("@undefinedFunction()"), 0644))
+
+	template.SetOption(OutputStdout, false)
+	template.SetOption(Overwrite, true)
+	resultFiles, err = template.ProcessTemplates("", "", append(templates, invalid, shebang)...)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "undefinedFunction")
+
+	// The shebang option should only affect its own file
+	assert.Equal(t, templates, resultFiles)
+	assert.False(t, template.options[OutputStdout])
+	for i := range templates {
+		content, _ := os.ReadFile(templates[i])
+		assert.Equal(t, fmt.Sprint(i), string(content))
+	}
+	assert.Equal(t, "2", output.String())
+}
+
+func TestTemplateFilesJobsAliases(t *testing.T) {
+	// This test is meant to be run with -race, the files define aliases and assign global variables concurrently
+	folder := t.TempDir()
+	var templates []string
+	for i := 0; i < 16; i++ {
+		file := path.Join(folder, fmt.Sprintf("file%d.txt", i))
+		content := fmt.Sprintf(`@alias("twice%[1]d", "template", "twice")@value := %[1]d@include("twice", $)`, i)
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		templates = append(templates, file)
+	}
+	assert.NoError(t, os.WriteFile(path.Join(folder, "ext.gte"), []byte(`@define("twice")@(value * 2)@end`), 0644))
+
+	template := MustNewTemplate(folder, map[string]interface{}{"value": 0}, "", nil).Jobs(8)
+	template.SetOption(Overwrite, true)
+	template.SetOption(StrictErrorCheck, true)
+	resultFiles, err := template.ProcessTemplates("", "", templates...)
+	assert.NoError(t, err)
+	assert.Equal(t, templates, resultFiles)
+	for i := range templates {
+		content, _ := os.ReadFile(templates[i])
+		assert.Equal(t, fmt.Sprint(i*2), string(content))
+	}
+}
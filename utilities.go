@@ -17,6 +17,7 @@ var must = errors.Must
 
 func cleanup() {
 	os.RemoveAll(tempFolder)
+	template.StopPlugins()
 
 	if err := recover(); err != nil {
 		switch err := err.(type) {
@@ -85,3 +86,15 @@ func extend(values []string) []string {
 	}
 	return result
 }
+
+// Returns the absolute path of the supplied files (only if they exist, other values are returned as is)
+func absFiles(files []string) []string {
+	result := make([]string, len(files))
+	for i := range files {
+		result[i] = files[i]
+		if _, err := os.Stat(files[i]); err == nil {
+			result[i] = must(filepath.Abs(files[i])).(string)
+		}
+	}
+	return result
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// UnifiedDiff returns the unified differences (with 3 lines of context) between the two contents.
+// It returns an empty string if the contents are identical.
+func UnifiedDiff(fromFile, toFile, from, to string) (string, error) {
+	if from == to {
+		return "", nil
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitDiffLines(from),
+		B:        splitDiffLines(to),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+}
+
+// Split the content in lines (keeping the end of line) and mark the last line if it does not end with a newline.
+func splitDiffLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n\\ No newline at end of file\n"
+	}
+	return lines
+}
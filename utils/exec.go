@@ -1,12 +1,14 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // https://regex101.com/r/ykVKPt/6
@@ -31,8 +33,24 @@ func ScriptParts(content string) (program, subprogram, source string) {
 	return
 }
 
+// Delay given to a cancelled command to release its output once the process has been killed (the sub processes
+// started by a shell script may keep them open).
+const commandWaitDelay = time.Second
+
+func newCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = commandWaitDelay
+	return cmd
+}
+
 // GetCommandFromFile returns an exec.Cmd structure to run the supplied script file
 func GetCommandFromFile(filename string, args ...interface{}) (cmd *exec.Cmd, err error) {
+	return GetCommandFromFileContext(context.Background(), filename, args...)
+}
+
+// GetCommandFromFileContext returns an exec.Cmd structure to run the supplied script file, the process is killed if
+// the context is done before the command completes
+func GetCommandFromFileContext(ctx context.Context, filename string, args ...interface{}) (cmd *exec.Cmd, err error) {
 	script, err := os.ReadFile(filename)
 	if err != nil {
 		return
@@ -60,18 +78,33 @@ func GetCommandFromFile(filename string, args ...interface{}) (cmd *exec.Cmd, er
 		}
 	}
 
-	cmd = exec.Command(command, strArgs...)
+	cmd = newCommand(ctx, command, strArgs...)
 	return
 }
 
 // GetCommandFromString returns an exec.Cmd structure to run the supplied command
 func GetCommandFromString(script string, args ...interface{}) (cmd *exec.Cmd, tempFile string, err error) {
+	return GetCommandFromStringContext(context.Background(), script, args...)
+}
+
+// GetCommandFromStringContext returns an exec.Cmd structure to run the supplied command, the process is killed if
+// the context is done before the command completes
+func GetCommandFromStringContext(ctx context.Context, script string, args ...interface{}) (cmd *exec.Cmd, tempFile string, err error) {
+	return GetCommandFromStringWithShell(ctx, "", script, args...)
+}
+
+// GetCommandFromStringWithShell returns an exec.Cmd structure to run the supplied command, the shell is used to run
+// the commands that are not directly executable (default to the first shell found on the system)
+func GetCommandFromStringWithShell(ctx context.Context, shell, script string, args ...interface{}) (cmd *exec.Cmd, tempFile string, err error) {
+	if shell == "" {
+		shell = getDefaultShell()
+	}
 	if executer, delegate, command := ScriptParts(strings.TrimSpace(script)); executer != "" {
-		cmd, tempFile, err = saveTempFile(fmt.Sprintf("#! %s %s\n%s", executer, delegate, command), args...)
+		cmd, tempFile, err = saveTempFile(ctx, fmt.Sprintf("#! %s %s\n%s", executer, delegate, command), args...)
 	} else {
 		strArgs := GlobFunc(args...)
 		if _, err = exec.LookPath(command); err == nil {
-			cmd = exec.Command(command, strArgs...)
+			cmd = newCommand(ctx, command, strArgs...)
 			return
 		}
 		if IsCommand(command) {
@@ -79,7 +112,7 @@ func GetCommandFromString(script string, args ...interface{}) (cmd *exec.Cmd, te
 			return
 		}
 
-		if cmd, tempFile, err = saveTempFile(fmt.Sprintf("#! %s\n%s", getDefaultShell(), command), args...); err != nil {
+		if cmd, tempFile, err = saveTempFile(ctx, fmt.Sprintf("#! %s\n%s", shell, command), args...); err != nil {
 			return
 		}
 	}
@@ -91,7 +124,7 @@ func IsCommand(command string) bool {
 	return !strings.ContainsAny(command, " \t|&$,;(){}<>[]")
 }
 
-func saveTempFile(content string, args ...interface{}) (cmd *exec.Cmd, fileName string, err error) {
+func saveTempFile(ctx context.Context, content string, args ...interface{}) (cmd *exec.Cmd, fileName string, err error) {
 	var temp *os.File
 	if temp, err = os.CreateTemp("", "exec_"); err != nil {
 		return
@@ -105,7 +138,7 @@ func saveTempFile(content string, args ...interface{}) (cmd *exec.Cmd, fileName
 	}
 	temp.Close()
 	fileName = temp.Name()
-	cmd, err = GetCommandFromFile(fileName)
+	cmd, err = GetCommandFromFileContext(ctx, fileName)
 	return
 }
 
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -75,6 +77,37 @@ func FindFilesMaxDepth(folder string, maxDepth int, followLinks bool, patterns .
 	return walker(folder)
 }
 
+// FindFilesFS returns the list of the files of the file system matching the array of patterns (the returned names are
+// slash separated paths relative to the root of the file system)
+func FindFilesFS(fsys fs.FS, folder string, maxDepth int, patterns ...string) (results []string, err error) {
+	folder = path.Clean(filepath.ToSlash(folder))
+	err = fs.WalkDir(fsys, folder, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			relative := name
+			if folder != "." {
+				relative = strings.TrimPrefix(name, folder+"/")
+			}
+			if name != folder && strings.Count(relative, "/") >= maxDepth {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		for _, pattern := range patterns {
+			if matched, err := path.Match(pattern, entry.Name()); err != nil {
+				return err
+			} else if matched {
+				results = append(results, name)
+				break
+			}
+		}
+		return nil
+	})
+	return
+}
+
 // FindFiles returns the list of files in the specified folder that match one of the supplied patterns
 func findFiles(folder string, patterns ...string) ([]string, error) {
 	var matches []string
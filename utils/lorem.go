@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"math/rand"
 	"strings"
 
 	goLorem "github.com/drhodes/goLorem"
@@ -69,3 +70,105 @@ func Lorem(kind LoremKind, params ...int) (string, error) {
 		return "", fmt.Errorf("unknown lorem type %v", kind)
 	}
 }
+
+// Words used by the lorem generator driven by a random source (goLorem always relies on the global random source).
+var loremWords = strings.Fields(`lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut
+	labore et dolore magna aliqua enim ad minim veniam quis nostrud exercitation ullamco laboris nisi aliquip ex ea commodo
+	consequat duis aute irure in reprehenderit voluptate velit esse cillum fugiat nulla pariatur excepteur sint occaecat
+	cupidatat non proident sunt culpa qui officia deserunt mollit anim id est laborum`)
+
+// LoremFromSource generates random string using lorem ipsum words, the result only depends on the supplied random source.
+func LoremFromSource(source *rand.Rand, kind LoremKind, params ...int) (string, error) {
+	min := 3
+	max := 10
+	if len(params) > 0 {
+		min = params[0]
+	}
+	if len(params) > 1 {
+		max = params[1]
+	}
+	generator := loremGenerator{source}
+	switch kind {
+	case Sentence:
+		return generator.sentence(min, max), nil
+	case Paragraph:
+		return generator.paragraph(min, max), nil
+	case Word:
+		return generator.word(min, max), nil
+	case Host:
+		return generator.host(), nil
+	case EMail:
+		return generator.word(4, 10) + "@" + generator.host(), nil
+	case URL:
+		return generator.url(), nil
+	default:
+		return "", fmt.Errorf("unknown lorem type %v", kind)
+	}
+}
+
+type loremGenerator struct{ *rand.Rand }
+
+// Returns a number between min (inclusive) and max (exclusive).
+func (g loremGenerator) between(min, max int) int {
+	if min > max {
+		min, max = max, min
+	}
+	if min == max {
+		return min
+	}
+	return min + g.Intn(max-min)
+}
+
+// Returns a word with a length between min and max.
+func (g loremGenerator) word(min, max int) string {
+	length := g.between(min, max)
+	var candidates []string
+	for _, word := range loremWords {
+		if len(word) == length {
+			candidates = append(candidates, word)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = loremWords
+	}
+	return candidates[g.Intn(len(candidates))]
+}
+
+func (g loremGenerator) sentence(min, max int) string {
+	count := g.between(min, max)
+	if count < 1 {
+		count = 1
+	}
+	words := make([]string, count)
+	for i := range words {
+		words[i] = loremWords[g.Intn(len(loremWords))]
+		if i > 2 && i < count-1 && g.Intn(count) == 0 {
+			words[i-1] += ","
+		}
+	}
+	words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+	return strings.Join(words, " ") + "."
+}
+
+func (g loremGenerator) paragraph(min, max int) string {
+	sentences := make([]string, g.between(min, max))
+	for i := range sentences {
+		sentences[i] = g.sentence(5, 22)
+	}
+	return strings.Join(sentences, " ")
+}
+
+func (g loremGenerator) host() string {
+	return g.word(2, 8) + g.word(2, 8) + []string{".com", ".net", ".org"}[g.Intn(3)]
+}
+
+func (g loremGenerator) url() string {
+	url := "http://www." + g.host()
+	switch g.Intn(3) {
+	case 1:
+		url += "/" + g.word(2, 8)
+	case 2:
+		url += "/" + g.word(2, 8) + "/" + g.word(2, 8) + ".html"
+	}
+	return url
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"math/rand"
 	"strings"
 	"testing"
 )
@@ -32,3 +33,19 @@ func TestLorem(t *testing.T) {
 		})
 	}
 }
+
+func TestLoremFromSource(t *testing.T) {
+	t.Parallel()
+	for _, kind := range []LoremKind{Word, Sentence, Paragraph, Host, EMail, URL} {
+		first, err := LoremFromSource(rand.New(rand.NewSource(42)), kind)
+		if err != nil || strings.TrimSpace(first) == "" {
+			t.Errorf("LoremFromSource(%v) = %q, %v", kind, first, err)
+		}
+		if second, _ := LoremFromSource(rand.New(rand.NewSource(42)), kind); first != second {
+			t.Errorf("LoremFromSource(%v) is not reproducible: %q != %q", kind, first, second)
+		}
+	}
+	if _, err := LoremFromSource(rand.New(rand.NewSource(42)), LoremKind(0)); err == nil {
+		t.Error("LoremFromSource() should fail for an unknown kind")
+	}
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileWatcher polls a list of files and reports the files that have been modified, added or removed.
+type FileWatcher struct {
+	interval time.Duration
+	list     func() []string
+	state    map[string]fileState
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewFileWatcher returns a FileWatcher that polls the files returned by the list function at every interval.
+// The list function is called on every poll, so it could return new files that should be watched.
+func NewFileWatcher(interval time.Duration, list func() []string) *FileWatcher {
+	w := &FileWatcher{interval: interval, list: list}
+	w.state = w.snapshot()
+	return w
+}
+
+func (w *FileWatcher) snapshot() map[string]fileState {
+	state := make(map[string]fileState)
+	for _, file := range w.list() {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			state[file] = fileState{info.ModTime(), info.Size()}
+		}
+	}
+	return state
+}
+
+// Changes returns the sorted list of files (absolute path) that changed since the last call.
+func (w *FileWatcher) Changes() (result []string) {
+	state := w.snapshot()
+	for file, current := range state {
+		if previous, found := w.state[file]; !found || previous != current {
+			result = append(result, file)
+		}
+	}
+	for file := range w.state {
+		if _, found := state[file]; !found {
+			result = append(result, file)
+		}
+	}
+	w.state = state
+	sort.Strings(result)
+	return
+}
+
+// Watch polls the files until the stop channel is closed and calls the handler with the list of changed files.
+// The changes are debounced, the handler is only called when no other change has been detected during a
+// complete interval.
+func (w *FileWatcher) Watch(stop <-chan struct{}, handler func(changes []string)) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	pending := make(map[string]bool)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if changes := w.Changes(); len(changes) > 0 {
+				for _, file := range changes {
+					pending[file] = true
+				}
+				continue
+			}
+			if len(pending) == 0 {
+				continue
+			}
+			changes := make([]string, 0, len(pending))
+			for file := range pending {
+				changes = append(changes, file)
+			}
+			sort.Strings(changes)
+			pending = make(map[string]bool)
+			handler(changes)
+		}
+	}
+}
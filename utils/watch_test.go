@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileWatcherChanges(t *testing.T) {
+	folder := t.TempDir()
+	file1, file2 := filepath.Join(folder, "file1.gt"), filepath.Join(folder, "file2.gt")
+	assert.NoError(t, os.WriteFile(file1, []byte("1"), 0644))
+
+	watcher := NewFileWatcher(time.Millisecond, func() []string { return MustFindFiles(folder, false, false, "*.gt") })
+	assert.Empty(t, watcher.Changes())
+
+	assert.NoError(t, os.WriteFile(file1, []byte("modified"), 0644))
+	assert.Equal(t, []string{file1}, watcher.Changes())
+	assert.Empty(t, watcher.Changes())
+
+	assert.NoError(t, os.WriteFile(file2, []byte("new"), 0644))
+	assert.Equal(t, []string{file2}, watcher.Changes())
+
+	assert.NoError(t, os.Remove(file1))
+	assert.Equal(t, []string{file1}, watcher.Changes())
+}
+
+func TestFileWatcherWatch(t *testing.T) {
+	folder := t.TempDir()
+	file1, file2 := filepath.Join(folder, "file1.gt"), filepath.Join(folder, "file2.gt")
+	assert.NoError(t, os.WriteFile(file1, []byte("1"), 0644))
+
+	watcher := NewFileWatcher(10*time.Millisecond, func() []string { return MustFindFiles(folder, false, false, "*.gt") })
+	stop, notifications := make(chan struct{}), make(chan []string)
+	go watcher.Watch(stop, func(changes []string) { notifications <- changes })
+	defer close(stop)
+
+	// Both changes should be reported in the same notification
+	assert.NoError(t, os.WriteFile(file1, []byte("modified"), 0644))
+	assert.NoError(t, os.WriteFile(file2, []byte("new"), 0644))
+
+	select {
+	case changes := <-notifications:
+		assert.Equal(t, []string{file1, file2}, changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Changes have not been detected")
+	}
+}
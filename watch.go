@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/coveooss/gotemplate/v3/collections"
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/coveooss/gotemplate/v3/utils"
+)
+
+// Returns the values of the named variables (--var) that are files (their content is loaded in the context).
+func namedVarFiles(namedVars []string) (result []string) {
+	for _, namedVar := range namedVars {
+		name, value := collections.Split2(namedVar, "=")
+		if value == "" {
+			value = name
+		}
+		if info, err := os.Stat(value); err == nil && !info.IsDir() {
+			result = append(result, value)
+		}
+	}
+	return
+}
+
+// Watch the template files, the imported files (including the optional ones and the named variables files) and the
+// extension files until the stop channel is closed.
+// When a template is modified, only that template is rendered again. A change to an import or an extension
+// file invalidates everything, so a new template is created and all templates are rendered again.
+func watchTemplates(stop <-chan struct{}, interval time.Duration, t *template.Template, imports []string, findTemplates func() []string, createTemplate func() *template.Template, render func(*template.Template, ...string)) {
+	dependencies := func() map[string]bool {
+		result := make(map[string]bool)
+		for _, file := range append(t.ExtensionFiles(), imports...) {
+			if abs, err := filepath.Abs(file); err == nil {
+				result[abs] = true
+			}
+		}
+		return result
+	}
+
+	watcher := utils.NewFileWatcher(interval, func() []string {
+		files := findTemplates()
+		for file := range dependencies() {
+			files = append(files, file)
+		}
+		return files
+	})
+
+	template.InternalLog.Info("Watching for changes")
+	watcher.Watch(stop, func(changes []string) {
+		isDependency := dependencies()
+		modified := make([]string, 0, len(changes))
+		for _, file := range changes {
+			if isDependency[file] || filepath.Ext(file) == ".gte" {
+				template.InternalLog.Infof("%s changed, rendering all templates", utils.Relative(utils.Pwd(), file))
+				if newTemplate := createTemplate(); newTemplate != nil {
+					t = newTemplate
+				}
+				render(t, findTemplates()...)
+				return
+			}
+			if _, err := os.Stat(file); err == nil {
+				// We do not render deleted templates
+				modified = append(modified, file)
+			}
+		}
+		if len(modified) > 0 {
+			render(t, modified...)
+		}
+	})
+}
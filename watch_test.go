@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coveooss/gotemplate/v3/template"
+	"github.com/coveooss/gotemplate/v3/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchTemplates(t *testing.T) {
+	folder := t.TempDir()
+	template1, template2 := filepath.Join(folder, "file1.gt"), filepath.Join(folder, "file2.gt")
+	extension, importFile := filepath.Join(folder, "ext.gte"), filepath.Join(folder, "vars.json")
+	optionalImport := filepath.Join(folder, "optional.json")
+	for file, content := range map[string]string{template1: "1", template2: "2", importFile: "{}", extension: ""} {
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	}
+
+	var created int32
+	rendered := make(chan []string)
+	createTemplate := func() *template.Template {
+		atomic.AddInt32(&created, 1)
+		return template.MustNewTemplate(folder, nil, "", nil)
+	}
+	findTemplates := func() []string { return utils.MustFindFiles(folder, false, false, "*.gt") }
+	render := func(t *template.Template, templates ...string) { rendered <- templates }
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go watchTemplates(stop, 10*time.Millisecond, createTemplate(), []string{importFile, optionalImport}, findTemplates, createTemplate, render)
+
+	waitRender := func() []string {
+		select {
+		case templates := <-rendered:
+			return templates
+		case <-time.After(5 * time.Second):
+			t.Fatal("Changes have not been detected")
+		}
+		return nil
+	}
+
+	// Only the modified template is rendered
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, os.WriteFile(template2, []byte("modified"), 0644))
+	assert.Equal(t, []string{template2}, waitRender())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&created))
+
+	// A modified import invalidates everything
+	assert.NoError(t, os.WriteFile(importFile, []byte(`{"value": 1}`), 0644))
+	assert.Equal(t, []string{template1, template2}, waitRender())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&created))
+
+	// An optional import created after the start invalidates everything
+	assert.NoError(t, os.WriteFile(optionalImport, []byte(`{"value": 2}`), 0644))
+	assert.Equal(t, []string{template1, template2}, waitRender())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&created))
+
+	// A modified extension invalidates everything
+	assert.NoError(t, os.WriteFile(extension, []byte(`@define("test")test@end`), 0644))
+	assert.Equal(t, []string{template1, template2}, waitRender())
+	assert.Equal(t, int32(4), atomic.LoadInt32(&created))
+}
+
+func TestNamedVarFiles(t *testing.T) {
+	folder := t.TempDir()
+	file := filepath.Join(folder, "values.yml")
+	assert.NoError(t, os.WriteFile(file, []byte("a: 1"), 0644))
+
+	assert.Equal(t, []string{file, file}, namedVarFiles([]string{"values=" + file, file, "name=value", "flag", "dir=" + folder}))
+}